@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// AsBatch c 实现了 BatchCache 时直接返回，否则返回逐个 key 调用 c 的适配器
+func AsBatch(c Cache) BatchCache {
+	if b, ok := c.(BatchCache); ok {
+		return b
+	}
+	return &batchAdapter{Cache: c}
+}
+
+type batchAdapter struct {
+	Cache
+}
+
+func (b *batchAdapter) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	res := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		val, err := b.Get(ctx, key)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return res, err
+		}
+		res[key] = val
+	}
+	return res, nil
+}
+
+func (b *batchAdapter) SetMulti(ctx context.Context, vals map[string][]byte, expiration time.Duration) error {
+	for key, val := range vals {
+		if err := b.Set(ctx, key, val, expiration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *batchAdapter) DeleteMulti(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		if err := b.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
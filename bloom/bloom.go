@@ -0,0 +1,153 @@
+package bloom
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// ErrInvalidData UnmarshalBinary 的数据格式不正确
+var ErrInvalidData = errors.New("bloom: invalid data")
+
+// Filter 布隆过滤器，Test 返回 false 时 key 一定没有添加过，返回 true 时可能误判
+type Filter interface {
+	Add(key string)
+	Test(key string) bool
+}
+
+var (
+	_ Filter = &BloomFilter{}
+	_ Filter = &CountingBloomFilter{}
+	_ Filter = &ScalableBloomFilter{}
+)
+
+// Populate 通过 enumerate 遍历数据源中已有的 key 并添加到 f，用于启动时预热
+func Populate(ctx context.Context, f Filter, enumerate func(ctx context.Context, add func(key string)) error) error {
+	return enumerate(ctx, f.Add)
+}
+
+// BloomFilter 标准布隆过滤器，并发安全
+type BloomFilter struct {
+	mutex *sync.RWMutex
+	bits  []uint64
+	m     uint64
+	k     uint64
+	//capacity 为预期的元素数量，n 为已添加的次数
+	capacity uint64
+	n        uint64
+}
+
+// NewBloomFilter 按预期元素数量 capacity 和误判率 fpRate 计算位数和哈希函数个数
+func NewBloomFilter(capacity uint64, fpRate float64) *BloomFilter {
+	m, k := optimal(capacity, fpRate)
+	return &BloomFilter{
+		mutex:    &sync.RWMutex{},
+		bits:     make([]uint64, (m+63)/64),
+		m:        m,
+		k:        k,
+		capacity: capacity,
+	}
+}
+
+func (f *BloomFilter) Add(key string) {
+	h1, h2 := hash(key)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for i := uint64(0); i < f.k; i++ {
+		idx := (h1 + i*h2) % f.m
+		f.bits[idx/64] |= 1 << (idx % 64)
+	}
+	f.n++
+}
+
+func (f *BloomFilter) Test(key string) bool {
+	h1, h2 := hash(key)
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	for i := uint64(0); i < f.k; i++ {
+		idx := (h1 + i*h2) % f.m
+		if f.bits[idx/64]&(1<<(idx%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count 已添加的次数，重复添加同一个 key 会重复计数
+func (f *BloomFilter) Count() uint64 {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return f.n
+}
+
+// MarshalBinary 格式为 m、k、capacity、n 各 8 字节，之后是位数组，均为大端
+func (f *BloomFilter) MarshalBinary() ([]byte, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	res := make([]byte, 32+8*len(f.bits))
+	binary.BigEndian.PutUint64(res, f.m)
+	binary.BigEndian.PutUint64(res[8:], f.k)
+	binary.BigEndian.PutUint64(res[16:], f.capacity)
+	binary.BigEndian.PutUint64(res[24:], f.n)
+	for i, w := range f.bits {
+		binary.BigEndian.PutUint64(res[32+8*i:], w)
+	}
+	return res, nil
+}
+
+func (f *BloomFilter) UnmarshalBinary(data []byte) error {
+	if len(data) < 32 {
+		return ErrInvalidData
+	}
+	m, k := binary.BigEndian.Uint64(data), binary.BigEndian.Uint64(data[8:])
+	words := (m + 63) / 64
+	if m == 0 || k == 0 || uint64(len(data)-32) != 8*words {
+		return ErrInvalidData
+	}
+	bits := make([]uint64, words)
+	for i := range bits {
+		bits[i] = binary.BigEndian.Uint64(data[32+8*i:])
+	}
+	if f.mutex == nil {
+		f.mutex = &sync.RWMutex{}
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.m, f.k, f.bits = m, k, bits
+	f.capacity = binary.BigEndian.Uint64(data[16:])
+	f.n = binary.BigEndian.Uint64(data[24:])
+	return nil
+}
+
+// optimal m = -n*ln(p)/(ln2)^2，k = m/n*ln2
+func optimal(capacity uint64, fpRate float64) (uint64, uint64) {
+	if capacity == 0 {
+		capacity = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	n := float64(capacity)
+	m := math.Ceil(-n * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / n * math.Ln2)
+	if k < 1 {
+		k = 1
+	}
+	return uint64(m), uint64(k)
+}
+
+// hash 双重哈希的两个基础哈希值，h2 为奇数
+func hash(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	h1 := h.Sum64()
+	//splitmix64 打散 h1 得到 h2
+	h2 := h1 + 0x9e3779b97f4a7c15
+	h2 = (h2 ^ (h2 >> 30)) * 0xbf58476d1ce4e5b9
+	h2 = (h2 ^ (h2 >> 27)) * 0x94d049bb133111eb
+	h2 ^= h2 >> 31
+	return h1, h2 | 1
+}
@@ -0,0 +1,76 @@
+package bloom
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+)
+
+// fpRate 添加 n 个 key 后用另外 n 个 key 统计误判率，添加过的 key 必须全部判断为存在
+func fpRate(t *testing.T, f Filter, n int) float64 {
+	for i := 0; i < n; i++ {
+		f.Add("key-" + strconv.Itoa(i))
+	}
+	for i := 0; i < n; i++ {
+		require.True(t, f.Test("key-"+strconv.Itoa(i)))
+	}
+	fp := 0
+	for i := 0; i < n; i++ {
+		if f.Test("absent-" + strconv.Itoa(i)) {
+			fp++
+		}
+	}
+	return float64(fp) / float64(n)
+}
+
+func TestBloomFilter_FPRate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		fpRate float64
+	}{
+		{name: "1%", fpRate: 0.01},
+		{name: "0.1%", fpRate: 0.001},
+		{name: "5%", fpRate: 0.05},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			observed := fpRate(t, NewBloomFilter(100000, tc.fpRate), 100000)
+			t.Logf("configured %v, observed %v", tc.fpRate, observed)
+			assert.Less(t, observed, tc.fpRate*1.5)
+		})
+	}
+}
+
+func TestBloomFilter_Marshal(t *testing.T) {
+	f := NewBloomFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		f.Add(strconv.Itoa(i))
+	}
+	data, err := f.MarshalBinary()
+	require.NoError(t, err)
+
+	f2 := &BloomFilter{}
+	require.NoError(t, f2.UnmarshalBinary(data))
+	assert.Equal(t, uint64(1000), f2.Count())
+	for i := 0; i < 2000; i++ {
+		assert.Equal(t, f.Test(strconv.Itoa(i)), f2.Test(strconv.Itoa(i)))
+	}
+	assert.Equal(t, ErrInvalidData, f2.UnmarshalBinary(data[:len(data)-1]))
+	assert.Equal(t, ErrInvalidData, f2.UnmarshalBinary(nil))
+}
+
+func TestPopulate(t *testing.T) {
+	f := NewBloomFilter(100, 0.01)
+	err := Populate(context.Background(), f, func(ctx context.Context, add func(key string)) error {
+		for i := 0; i < 100; i++ {
+			add("key-" + strconv.Itoa(i))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.True(t, f.Test("key-0"))
+	assert.True(t, f.Test("key-99"))
+	assert.Equal(t, uint64(100), f.Count())
+}
@@ -0,0 +1,98 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+)
+
+// CountingBloomFilter 每一位使用 8 位计数器的布隆过滤器，支持删除，计数器达到 255 后不再增减
+type CountingBloomFilter struct {
+	mutex    *sync.RWMutex
+	counters []uint8
+	m        uint64
+	k        uint64
+}
+
+func NewCountingBloomFilter(capacity uint64, fpRate float64) *CountingBloomFilter {
+	m, k := optimal(capacity, fpRate)
+	return &CountingBloomFilter{
+		mutex:    &sync.RWMutex{},
+		counters: make([]uint8, m),
+		m:        m,
+		k:        k,
+	}
+}
+
+func (f *CountingBloomFilter) Add(key string) {
+	h1, h2 := hash(key)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for i := uint64(0); i < f.k; i++ {
+		idx := (h1 + i*h2) % f.m
+		if f.counters[idx] < math.MaxUint8 {
+			f.counters[idx]++
+		}
+	}
+}
+
+// Remove 只能删除添加过的 key，删除未添加过的 key 会导致其他 key 被误判为不存在
+func (f *CountingBloomFilter) Remove(key string) {
+	h1, h2 := hash(key)
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.test(h1, h2) {
+		return
+	}
+	for i := uint64(0); i < f.k; i++ {
+		idx := (h1 + i*h2) % f.m
+		if f.counters[idx] > 0 && f.counters[idx] < math.MaxUint8 {
+			f.counters[idx]--
+		}
+	}
+}
+
+func (f *CountingBloomFilter) Test(key string) bool {
+	h1, h2 := hash(key)
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return f.test(h1, h2)
+}
+
+func (f *CountingBloomFilter) test(h1, h2 uint64) bool {
+	for i := uint64(0); i < f.k; i++ {
+		if f.counters[(h1+i*h2)%f.m] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalBinary 格式为 m、k 各 8 字节，之后每个计数器 1 字节
+func (f *CountingBloomFilter) MarshalBinary() ([]byte, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	res := make([]byte, 16+len(f.counters))
+	binary.BigEndian.PutUint64(res, f.m)
+	binary.BigEndian.PutUint64(res[8:], f.k)
+	copy(res[16:], f.counters)
+	return res, nil
+}
+
+func (f *CountingBloomFilter) UnmarshalBinary(data []byte) error {
+	if len(data) < 16 {
+		return ErrInvalidData
+	}
+	m, k := binary.BigEndian.Uint64(data), binary.BigEndian.Uint64(data[8:])
+	if m == 0 || k == 0 || uint64(len(data)-16) != m {
+		return ErrInvalidData
+	}
+	if f.mutex == nil {
+		f.mutex = &sync.RWMutex{}
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.m, f.k = m, k
+	f.counters = append([]uint8(nil), data[16:]...)
+	return nil
+}
@@ -0,0 +1,53 @@
+package bloom
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+)
+
+func TestCountingBloomFilter_FPRate(t *testing.T) {
+	observed := fpRate(t, NewCountingBloomFilter(100000, 0.01), 100000)
+	t.Logf("configured 0.01, observed %v", observed)
+	assert.Less(t, observed, 0.015)
+}
+
+func TestCountingBloomFilter_Remove(t *testing.T) {
+	f := NewCountingBloomFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		f.Add(strconv.Itoa(i))
+	}
+	for i := 0; i < 500; i++ {
+		f.Remove(strconv.Itoa(i))
+	}
+	//删除后不会影响其他 key
+	for i := 500; i < 1000; i++ {
+		assert.True(t, f.Test(strconv.Itoa(i)))
+	}
+	removed := 0
+	for i := 0; i < 500; i++ {
+		if !f.Test(strconv.Itoa(i)) {
+			removed++
+		}
+	}
+	assert.Greater(t, removed, 480)
+}
+
+func TestCountingBloomFilter_Marshal(t *testing.T) {
+	f := NewCountingBloomFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		f.Add(strconv.Itoa(i))
+	}
+	data, err := f.MarshalBinary()
+	require.NoError(t, err)
+
+	f2 := &CountingBloomFilter{}
+	require.NoError(t, f2.UnmarshalBinary(data))
+	f2.Remove("0")
+	f.Remove("0")
+	for i := 0; i < 2000; i++ {
+		assert.Equal(t, f.Test(strconv.Itoa(i)), f2.Test(strconv.Itoa(i)))
+	}
+	assert.Equal(t, ErrInvalidData, f2.UnmarshalBinary(data[:10]))
+}
@@ -0,0 +1,132 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+)
+
+const (
+	// scalableGrowth 每个新增的过滤器容量是上一个的倍数
+	scalableGrowth = 2
+	// scalableTightening 每个新增的过滤器误判率是上一个的倍数，使总误判率收敛到 fpRate 以内
+	scalableTightening = 0.8
+)
+
+// ScalableBloomFilter 元素数量超过当前过滤器容量时追加一个容量翻倍、误判率更低的过滤器，
+// 总误判率不超过 fpRate，适合无法预估元素数量的场景
+type ScalableBloomFilter struct {
+	mutex    *sync.RWMutex
+	filters  []*BloomFilter
+	capacity uint64
+	fpRate   float64
+}
+
+// NewScalableBloomFilter capacity 为第一个过滤器的容量
+func NewScalableBloomFilter(capacity uint64, fpRate float64) *ScalableBloomFilter {
+	res := &ScalableBloomFilter{
+		mutex:    &sync.RWMutex{},
+		capacity: capacity,
+		fpRate:   fpRate,
+	}
+	res.grow()
+	return res
+}
+
+// grow 第 i 个过滤器的误判率为 fpRate*(1-r)*r^i，总和不超过 fpRate
+func (f *ScalableBloomFilter) grow() {
+	i := len(f.filters)
+	capacity := f.capacity * uint64(math.Pow(scalableGrowth, float64(i)))
+	fpRate := f.fpRate * (1 - scalableTightening) * math.Pow(scalableTightening, float64(i))
+	f.filters = append(f.filters, NewBloomFilter(capacity, fpRate))
+}
+
+// Add 已判断为存在的 key 不再添加，避免重复计数导致过早扩容
+func (f *ScalableBloomFilter) Add(key string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.test(key) {
+		return
+	}
+	last := f.filters[len(f.filters)-1]
+	if last.Count() >= last.capacity {
+		f.grow()
+		last = f.filters[len(f.filters)-1]
+	}
+	last.Add(key)
+}
+
+func (f *ScalableBloomFilter) Test(key string) bool {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return f.test(key)
+}
+
+func (f *ScalableBloomFilter) test(key string) bool {
+	for _, filter := range f.filters {
+		if filter.Test(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalBinary 格式为 capacity、fpRate、过滤器个数各 8 字节，之后每个过滤器为 8 字节长度加 BloomFilter 的序列化结果
+func (f *ScalableBloomFilter) MarshalBinary() ([]byte, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	res := make([]byte, 24)
+	binary.BigEndian.PutUint64(res, f.capacity)
+	binary.BigEndian.PutUint64(res[8:], math.Float64bits(f.fpRate))
+	binary.BigEndian.PutUint64(res[16:], uint64(len(f.filters)))
+	for _, filter := range f.filters {
+		data, err := filter.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		var l [8]byte
+		binary.BigEndian.PutUint64(l[:], uint64(len(data)))
+		res = append(append(res, l[:]...), data...)
+	}
+	return res, nil
+}
+
+func (f *ScalableBloomFilter) UnmarshalBinary(data []byte) error {
+	if len(data) < 24 {
+		return ErrInvalidData
+	}
+	capacity := binary.BigEndian.Uint64(data)
+	fpRate := math.Float64frombits(binary.BigEndian.Uint64(data[8:]))
+	cnt := binary.BigEndian.Uint64(data[16:])
+	data = data[24:]
+	if cnt == 0 {
+		return ErrInvalidData
+	}
+	var filters []*BloomFilter
+	for i := uint64(0); i < cnt; i++ {
+		if len(data) < 8 {
+			return ErrInvalidData
+		}
+		l := binary.BigEndian.Uint64(data)
+		data = data[8:]
+		if uint64(len(data)) < l {
+			return ErrInvalidData
+		}
+		filter := &BloomFilter{}
+		if err := filter.UnmarshalBinary(data[:l]); err != nil {
+			return err
+		}
+		filters = append(filters, filter)
+		data = data[l:]
+	}
+	if len(data) != 0 {
+		return ErrInvalidData
+	}
+	if f.mutex == nil {
+		f.mutex = &sync.RWMutex{}
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.capacity, f.fpRate, f.filters = capacity, fpRate, filters
+	return nil
+}
@@ -0,0 +1,51 @@
+package bloom
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+)
+
+func TestScalableBloomFilter_FPRate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		capacity    uint64
+		n           int
+		wantFilters int
+	}{
+		{name: "within capacity", capacity: 100000, n: 100000, wantFilters: 1},
+		{name: "grow", capacity: 1000, n: 100000, wantFilters: 7},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := NewScalableBloomFilter(tc.capacity, 0.01)
+			observed := fpRate(t, f, tc.n)
+			t.Logf("configured 0.01, observed %v", observed)
+			assert.Less(t, observed, 0.015)
+			assert.Equal(t, tc.wantFilters, len(f.filters))
+		})
+	}
+}
+
+func TestScalableBloomFilter_Marshal(t *testing.T) {
+	f := NewScalableBloomFilter(100, 0.01)
+	for i := 0; i < 1000; i++ {
+		f.Add(strconv.Itoa(i))
+	}
+	data, err := f.MarshalBinary()
+	require.NoError(t, err)
+
+	f2 := &ScalableBloomFilter{}
+	require.NoError(t, f2.UnmarshalBinary(data))
+	assert.Equal(t, len(f.filters), len(f2.filters))
+	for i := 0; i < 2000; i++ {
+		assert.Equal(t, f.Test(strconv.Itoa(i)), f2.Test(strconv.Itoa(i)))
+	}
+	//恢复后继续扩容
+	for i := 1000; i < 2000; i++ {
+		f2.Add(strconv.Itoa(i))
+	}
+	assert.Greater(t, len(f2.filters), len(f.filters))
+	assert.Equal(t, ErrInvalidData, f2.UnmarshalBinary(data[:len(data)-1]))
+}
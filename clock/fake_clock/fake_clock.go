@@ -0,0 +1,83 @@
+package fake_clock
+
+import (
+	"github.com/ac-zht/cache/clock"
+	"sync"
+	"time"
+)
+
+var _ clock.Clock = &FakeClock{}
+
+// FakeClock 只有调用 Advance 时才会前进的时钟，到期的 Ticker 在 Advance 中触发
+type FakeClock struct {
+	mutex   *sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{
+		mutex: &sync.Mutex{},
+		now:   now,
+	}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) clock.Ticker {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	t := &fakeTicker{
+		c:      make(chan time.Time, 1),
+		period: d,
+		next:   c.now.Add(d),
+		clock:  c,
+	}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// Advance 时间前进 d，期间到期的 Ticker 各触发一次，和 time.Ticker 一样接收方来不及处理时丢弃多余的 tick
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.tickers {
+		if t.next.After(c.now) {
+			continue
+		}
+		for !t.next.After(c.now) {
+			t.next = t.next.Add(t.period)
+		}
+		select {
+		case t.c <- c.now:
+		default:
+		}
+	}
+}
+
+type fakeTicker struct {
+	c      chan time.Time
+	period time.Duration
+	next   time.Time
+	clock  *FakeClock
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	for i, ticker := range t.clock.tickers {
+		if ticker == t {
+			t.clock.tickers = append(t.clock.tickers[:i], t.clock.tickers[i+1:]...)
+			return
+		}
+	}
+}
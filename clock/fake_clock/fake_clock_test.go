@@ -0,0 +1,37 @@
+package fake_clock
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFakeClock_Advance(t *testing.T) {
+	start := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+	ticker := c.NewTicker(time.Second)
+
+	c.Advance(time.Millisecond * 500)
+	assert.Equal(t, start.Add(time.Millisecond*500), c.Now())
+	select {
+	case <-ticker.C():
+		t.Fatal("ticker should not fire")
+	default:
+	}
+
+	c.Advance(time.Second * 3)
+	select {
+	case now := <-ticker.C():
+		assert.Equal(t, start.Add(time.Millisecond*3500), now)
+	default:
+		t.Fatal("ticker should fire")
+	}
+
+	ticker.Stop()
+	c.Advance(time.Second * 2)
+	select {
+	case <-ticker.C():
+		t.Fatal("stopped ticker should not fire")
+	default:
+	}
+}
@@ -0,0 +1,94 @@
+package codec
+
+import (
+	"context"
+	"fmt"
+	"github.com/ac-zht/cache"
+	"time"
+)
+
+// EncodeError 写入缓存前序列化失败
+type EncodeError struct {
+	Key   string
+	Codec string
+	Err   error
+}
+
+func (e *EncodeError) Error() string {
+	return fmt.Sprintf("cache: %s encode fail, key : %s, reason: %s", e.Codec, e.Key, e.Err)
+}
+
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError 从缓存读出后反序列化失败
+type DecodeError struct {
+	Key   string
+	Codec string
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("cache: %s decode fail, key : %s, reason: %s", e.Codec, e.Key, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// CodecCache 借助 Codec 把任意 cache.Cache 包装成 cache.TypedCache[string, V]
+type CodecCache[V any] struct {
+	cache.Cache
+	codec Codec
+}
+
+func NewCodecCache[V any](c cache.Cache, codec Codec) *CodecCache[V] {
+	return &CodecCache[V]{
+		Cache: c,
+		codec: codec,
+	}
+}
+
+func (c *CodecCache[V]) Get(ctx context.Context, key string) (V, error) {
+	data, err := c.Cache.Get(ctx, key)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return c.decode(key, data)
+}
+
+func (c *CodecCache[V]) Set(ctx context.Context, key string, val V, expiration time.Duration) error {
+	data, err := c.codec.Marshal(val)
+	if err != nil {
+		return &EncodeError{Key: key, Codec: c.codec.Name(), Err: err}
+	}
+	return c.Cache.Set(ctx, key, data, expiration)
+}
+
+func (c *CodecCache[V]) LoadAndDelete(ctx context.Context, key string) (V, error) {
+	data, err := c.Cache.LoadAndDelete(ctx, key)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return c.decode(key, data)
+}
+
+// OnEvicted 反序列化失败时以零值回调，保证淘汰通知不丢失
+func (c *CodecCache[V]) OnEvicted(fn func(key string, val V)) {
+	c.Cache.OnEvicted(func(key string, data []byte) {
+		val, _ := c.decode(key, data)
+		fn(key, val)
+	})
+}
+
+func (c *CodecCache[V]) decode(key string, data []byte) (V, error) {
+	var val V
+	if err := c.codec.Unmarshal(data, &val); err != nil {
+		var zero V
+		return zero, &DecodeError{Key: key, Codec: c.codec.Name(), Err: err}
+	}
+	return val, nil
+}
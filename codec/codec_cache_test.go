@@ -0,0 +1,78 @@
+package codec
+
+import (
+	"context"
+	"errors"
+	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/local_cache"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCodecCache_Get(t *testing.T) {
+	testCase := []struct {
+		name      string
+		before    func(c cache.Cache)
+		key       string
+		wantVal   user
+		wantError error
+	}{
+		{
+			name: "get",
+			before: func(c cache.Cache) {
+				_ = c.Set(context.Background(), "key", []byte(`{"Id":1,"Name":"Tom"}`), time.Minute)
+			},
+			key:     "key",
+			wantVal: user{Id: 1, Name: "Tom"},
+		},
+		{
+			name:      "not exist",
+			before:    func(c cache.Cache) {},
+			key:       "key",
+			wantError: cache.ErrKeyNotFound,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			bc := local_cache.NewBytesCache(10)
+			tc.before(bc)
+			c := NewCodecCache[user](bc, JSONCodec{})
+			val, err := c.Get(context.Background(), tc.key)
+			assert.ErrorIs(t, err, tc.wantError)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantVal, val)
+		})
+	}
+}
+
+func TestCodecCache_DecodeError(t *testing.T) {
+	bc := local_cache.NewBytesCache(10)
+	_ = bc.Set(context.Background(), "key", []byte("not json"), time.Minute)
+	c := NewCodecCache[user](bc, JSONCodec{})
+	_, err := c.Get(context.Background(), "key")
+	var decodeErr *DecodeError
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, "key", decodeErr.Key)
+	assert.Equal(t, "json", decodeErr.Codec)
+}
+
+func TestCodecCache_SetAndLoadAndDelete(t *testing.T) {
+	var c cache.TypedCache[string, user] = NewCodecCache[user](local_cache.NewBytesCache(10), GobCodec{})
+	evicted := map[string]user{}
+	c.OnEvicted(func(key string, val user) {
+		evicted[key] = val
+	})
+	assert.NoError(t, c.Set(context.Background(), "key", user{Id: 1, Name: "Tom"}, time.Minute))
+	val, err := c.LoadAndDelete(context.Background(), "key")
+	assert.NoError(t, err)
+	assert.Equal(t, user{Id: 1, Name: "Tom"}, val)
+	assert.Equal(t, map[string]user{"key": {Id: 1, Name: "Tom"}}, evicted)
+
+	raw := NewCodecCache[chan int](local_cache.NewBytesCache(10), JSONCodec{})
+	err = raw.Set(context.Background(), "key", make(chan int), time.Minute)
+	var encodeErr *EncodeError
+	assert.True(t, errors.As(err, &encodeErr))
+}
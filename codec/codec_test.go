@@ -0,0 +1,53 @@
+package codec
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type user struct {
+	Id   int64
+	Name string
+}
+
+func TestCodec(t *testing.T) {
+	testCase := []struct {
+		name  string
+		codec Codec
+	}{
+		{
+			name:  "json",
+			codec: JSONCodec{},
+		},
+		{
+			name:  "gob",
+			codec: GobCodec{},
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := tc.codec.Marshal(user{Id: 1, Name: "Tom"})
+			require.NoError(t, err)
+			var u user
+			err = tc.codec.Unmarshal(data, &u)
+			require.NoError(t, err)
+			assert.Equal(t, user{Id: 1, Name: "Tom"}, u)
+		})
+	}
+}
+
+func TestRawCodec(t *testing.T) {
+	c := RawCodec{}
+	data, err := c.Marshal([]byte("value"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), data)
+
+	var s string
+	require.NoError(t, c.Unmarshal(data, &s))
+	assert.Equal(t, "value", s)
+
+	_, err = c.Marshal(user{})
+	assert.Error(t, err)
+	assert.Error(t, c.Unmarshal(data, &user{}))
+}
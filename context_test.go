@@ -0,0 +1,29 @@
+package cache
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type ctxKey struct{}
+
+func TestDetachContext(t *testing.T) {
+	parent, cancel := context.WithTimeout(context.WithValue(context.Background(), ctxKey{}, "trace-1"), time.Millisecond)
+	ctx := DetachContext(parent)
+	cancel()
+	<-parent.Done()
+
+	assert.NoError(t, ctx.Err())
+	assert.Nil(t, ctx.Done())
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+	assert.Equal(t, "trace-1", ctx.Value(ctxKey{}))
+
+	//在脱离后的 context 上重新设置超时
+	ctx, cancel = context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+}
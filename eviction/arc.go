@@ -0,0 +1,94 @@
+package eviction
+
+var _ Policy = &ARC{}
+
+// ARC 自适应替换策略，t1/t2 分别保存只访问过一次和多次的 key，
+// b1/b2 保存它们最近被淘汰的 key，根据 b1/b2 的命中情况调整 t1 的目标大小 p
+type ARC struct {
+	capacity int
+	p        int
+
+	t1, t2 *keyList
+	b1, b2 *keyList
+}
+
+func NewARC(capacity int) *ARC {
+	return &ARC{
+		capacity: capacity,
+		t1:       newKeyList(),
+		t2:       newKeyList(),
+		b1:       newKeyList(),
+		b2:       newKeyList(),
+	}
+}
+
+func (a *ARC) Add(key string) {
+	switch {
+	case a.t1.contains(key) || a.t2.contains(key):
+		a.Access(key)
+		return
+	case a.b1.contains(key):
+		a.p = minInt(a.capacity, a.p+maxInt(1, a.b2.len()/a.b1.len()))
+		a.b1.remove(key)
+		a.t2.pushBack(key)
+	case a.b2.contains(key):
+		a.p = maxInt(0, a.p-maxInt(1, a.b1.len()/a.b2.len()))
+		a.b2.remove(key)
+		a.t2.pushBack(key)
+	default:
+		a.t1.pushBack(key)
+	}
+	a.trim()
+}
+
+func (a *ARC) Access(key string) {
+	if a.t1.remove(key) {
+		a.t2.pushBack(key)
+		return
+	}
+	a.t2.moveToBack(key)
+}
+
+// Remove 被移除的 key 进入对应的 ghost 链表
+func (a *ARC) Remove(key string) {
+	if a.t1.remove(key) {
+		a.b1.pushBack(key)
+	} else if a.t2.remove(key) {
+		a.b2.pushBack(key)
+	}
+	a.trim()
+}
+
+func (a *ARC) Victim() (string, bool) {
+	if a.t1.len() > 0 && (a.t1.len() > a.p || a.t2.len() == 0) {
+		return a.t1.front()
+	}
+	return a.t2.front()
+}
+
+func (a *ARC) Len() int {
+	return a.t1.len() + a.t2.len()
+}
+
+func (a *ARC) trim() {
+	for a.t1.len()+a.b1.len() > a.capacity && a.b1.len() > 0 {
+		a.b1.popFront()
+	}
+	for a.t1.len()+a.t2.len()+a.b1.len()+a.b2.len() > 2*a.capacity && a.b2.len() > 0 {
+		a.b2.popFront()
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,27 @@
+package eviction
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestARC(t *testing.T) {
+	a := NewARC(2)
+	a.Add("k1")
+	a.Add("k2")
+	a.Access("k1")
+	//k1 进入 t2，优先淘汰 t1 中的 k2
+	victim, ok := a.Victim()
+	assert.True(t, ok)
+	assert.Equal(t, "k2", victim)
+
+	a.Remove("k2")
+	assert.True(t, a.b1.contains("k2"))
+	assert.Equal(t, 1, a.Len())
+
+	//命中 b1 的 ghost key 会增大 p 并直接进入 t2
+	a.Add("k2")
+	assert.Equal(t, 1, a.p)
+	assert.True(t, a.t2.contains("k2"))
+	assert.Equal(t, 2, a.Len())
+}
@@ -0,0 +1,40 @@
+package eviction
+
+var _ Policy = &FIFO{}
+
+// FIFO 淘汰最早写入的 key，访问不影响顺序
+type FIFO struct {
+	keys *keyList
+}
+
+func NewFIFO() *FIFO {
+	return &FIFO{
+		keys: newKeyList(),
+	}
+}
+
+func (f *FIFO) Add(key string) {
+	if f.keys.contains(key) {
+		return
+	}
+	f.keys.pushBack(key)
+}
+
+func (f *FIFO) Access(key string) {}
+
+func (f *FIFO) Remove(key string) {
+	f.keys.remove(key)
+}
+
+func (f *FIFO) Victim() (string, bool) {
+	return f.keys.front()
+}
+
+func (f *FIFO) Len() int {
+	return f.keys.len()
+}
+
+// Keys 按写入时间从旧到新返回所有 key
+func (f *FIFO) Keys() []string {
+	return f.keys.all()
+}
@@ -0,0 +1,24 @@
+package eviction
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFIFO(t *testing.T) {
+	f := NewFIFO()
+	f.Add("k1")
+	f.Add("k2")
+	f.Add("k3")
+	f.Access("k1")
+	f.Add("k1")
+	victim, ok := f.Victim()
+	assert.True(t, ok)
+	assert.Equal(t, "k1", victim)
+	assert.Equal(t, []string{"k1", "k2", "k3"}, f.Keys())
+
+	f.Remove("k1")
+	victim, _ = f.Victim()
+	assert.Equal(t, "k2", victim)
+	assert.Equal(t, 2, f.Len())
+}
@@ -0,0 +1,69 @@
+package eviction
+
+import "container/list"
+
+// keyList 带索引的 key 链表，Front 为最旧的 key
+type keyList struct {
+	keys  *list.List
+	index map[string]*list.Element
+}
+
+func newKeyList() *keyList {
+	return &keyList{
+		keys:  list.New(),
+		index: make(map[string]*list.Element),
+	}
+}
+
+func (l *keyList) contains(key string) bool {
+	_, ok := l.index[key]
+	return ok
+}
+
+func (l *keyList) pushBack(key string) {
+	l.index[key] = l.keys.PushBack(key)
+}
+
+func (l *keyList) moveToBack(key string) {
+	if elem, ok := l.index[key]; ok {
+		l.keys.MoveToBack(elem)
+	}
+}
+
+func (l *keyList) remove(key string) bool {
+	elem, ok := l.index[key]
+	if !ok {
+		return false
+	}
+	l.keys.Remove(elem)
+	delete(l.index, key)
+	return true
+}
+
+func (l *keyList) front() (string, bool) {
+	elem := l.keys.Front()
+	if elem == nil {
+		return "", false
+	}
+	return elem.Value.(string), true
+}
+
+func (l *keyList) popFront() (string, bool) {
+	key, ok := l.front()
+	if ok {
+		l.remove(key)
+	}
+	return key, ok
+}
+
+func (l *keyList) len() int {
+	return l.keys.Len()
+}
+
+func (l *keyList) all() []string {
+	res := make([]string, 0, l.keys.Len())
+	for elem := l.keys.Front(); elem != nil; elem = elem.Next() {
+		res = append(res, elem.Value.(string))
+	}
+	return res
+}
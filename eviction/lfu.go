@@ -0,0 +1,87 @@
+package eviction
+
+import "container/list"
+
+var _ Policy = &LFU{}
+
+// LFU 淘汰访问次数最少的 key，次数相同时淘汰最久未访问的
+type LFU struct {
+	//buckets 按访问次数从小到大排列，每个桶内按访问时间从旧到新排列
+	buckets *list.List
+	index   map[string]*lfuEntry
+}
+
+type lfuBucket struct {
+	freq int
+	keys *list.List
+}
+
+type lfuEntry struct {
+	bucket *list.Element
+	elem   *list.Element
+}
+
+func NewLFU() *LFU {
+	return &LFU{
+		buckets: list.New(),
+		index:   make(map[string]*lfuEntry),
+	}
+}
+
+func (l *LFU) Add(key string) {
+	if _, ok := l.index[key]; ok {
+		l.Access(key)
+		return
+	}
+	front := l.buckets.Front()
+	if front == nil || front.Value.(*lfuBucket).freq != 1 {
+		front = l.buckets.PushFront(&lfuBucket{freq: 1, keys: list.New()})
+	}
+	l.index[key] = &lfuEntry{
+		bucket: front,
+		elem:   front.Value.(*lfuBucket).keys.PushBack(key),
+	}
+}
+
+func (l *LFU) Access(key string) {
+	entry, ok := l.index[key]
+	if !ok {
+		return
+	}
+	cur := entry.bucket.Value.(*lfuBucket)
+	next := entry.bucket.Next()
+	if next == nil || next.Value.(*lfuBucket).freq != cur.freq+1 {
+		next = l.buckets.InsertAfter(&lfuBucket{freq: cur.freq + 1, keys: list.New()}, entry.bucket)
+	}
+	cur.keys.Remove(entry.elem)
+	if cur.keys.Len() == 0 {
+		l.buckets.Remove(entry.bucket)
+	}
+	entry.bucket = next
+	entry.elem = next.Value.(*lfuBucket).keys.PushBack(key)
+}
+
+func (l *LFU) Remove(key string) {
+	entry, ok := l.index[key]
+	if !ok {
+		return
+	}
+	b := entry.bucket.Value.(*lfuBucket)
+	b.keys.Remove(entry.elem)
+	if b.keys.Len() == 0 {
+		l.buckets.Remove(entry.bucket)
+	}
+	delete(l.index, key)
+}
+
+func (l *LFU) Victim() (string, bool) {
+	front := l.buckets.Front()
+	if front == nil {
+		return "", false
+	}
+	return front.Value.(*lfuBucket).keys.Front().Value.(string), true
+}
+
+func (l *LFU) Len() int {
+	return len(l.index)
+}
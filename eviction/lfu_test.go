@@ -0,0 +1,59 @@
+package eviction
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLFU(t *testing.T) {
+	testCase := []struct {
+		name       string
+		before     func(l *LFU)
+		wantVictim string
+		wantLen    int
+	}{
+		{
+			name: "least frequently",
+			before: func(l *LFU) {
+				l.Add("k1")
+				l.Add("k2")
+				l.Access("k1")
+			},
+			wantVictim: "k2",
+			wantLen:    2,
+		},
+		{
+			name: "same frequency evict oldest",
+			before: func(l *LFU) {
+				l.Add("k1")
+				l.Add("k2")
+				l.Access("k2")
+				l.Access("k1")
+			},
+			wantVictim: "k2",
+			wantLen:    2,
+		},
+		{
+			name: "remove",
+			before: func(l *LFU) {
+				l.Add("k1")
+				l.Add("k2")
+				l.Access("k1")
+				l.Access("k1")
+				l.Remove("k2")
+			},
+			wantVictim: "k1",
+			wantLen:    1,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			l := NewLFU()
+			tc.before(l)
+			victim, ok := l.Victim()
+			assert.True(t, ok)
+			assert.Equal(t, tc.wantVictim, victim)
+			assert.Equal(t, tc.wantLen, l.Len())
+		})
+	}
+}
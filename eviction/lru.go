@@ -0,0 +1,43 @@
+package eviction
+
+var _ Policy = &LRU{}
+
+// LRU 淘汰最久未被访问的 key
+type LRU struct {
+	keys *keyList
+}
+
+func NewLRU() *LRU {
+	return &LRU{
+		keys: newKeyList(),
+	}
+}
+
+func (l *LRU) Add(key string) {
+	if l.keys.contains(key) {
+		l.keys.moveToBack(key)
+		return
+	}
+	l.keys.pushBack(key)
+}
+
+func (l *LRU) Access(key string) {
+	l.keys.moveToBack(key)
+}
+
+func (l *LRU) Remove(key string) {
+	l.keys.remove(key)
+}
+
+func (l *LRU) Victim() (string, bool) {
+	return l.keys.front()
+}
+
+func (l *LRU) Len() int {
+	return l.keys.len()
+}
+
+// Keys 按访问时间从旧到新返回所有 key
+func (l *LRU) Keys() []string {
+	return l.keys.all()
+}
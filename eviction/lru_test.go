@@ -0,0 +1,59 @@
+package eviction
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLRU(t *testing.T) {
+	testCase := []struct {
+		name       string
+		before     func(l *LRU)
+		wantVictim string
+		wantKeys   []string
+	}{
+		{
+			name: "add",
+			before: func(l *LRU) {
+				l.Add("k1")
+				l.Add("k2")
+				l.Add("k3")
+			},
+			wantVictim: "k1",
+			wantKeys:   []string{"k1", "k2", "k3"},
+		},
+		{
+			name: "access",
+			before: func(l *LRU) {
+				l.Add("k1")
+				l.Add("k2")
+				l.Add("k3")
+				l.Access("k1")
+			},
+			wantVictim: "k2",
+			wantKeys:   []string{"k2", "k3", "k1"},
+		},
+		{
+			name: "remove",
+			before: func(l *LRU) {
+				l.Add("k1")
+				l.Add("k2")
+				l.Remove("k1")
+			},
+			wantVictim: "k2",
+			wantKeys:   []string{"k2"},
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			l := NewLRU()
+			tc.before(l)
+			victim, ok := l.Victim()
+			assert.True(t, ok)
+			assert.Equal(t, tc.wantVictim, victim)
+			assert.Equal(t, tc.wantKeys, l.Keys())
+		})
+	}
+	_, ok := NewLRU().Victim()
+	assert.False(t, ok)
+}
@@ -0,0 +1,56 @@
+package eviction
+
+import (
+	"math/rand"
+	"time"
+)
+
+var _ Policy = &Random{}
+
+// Random 随机淘汰一个 key
+type Random struct {
+	keys  []string
+	index map[string]int
+	rand  *rand.Rand
+}
+
+func NewRandom() *Random {
+	return &Random{
+		index: make(map[string]int),
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (r *Random) Add(key string) {
+	if _, ok := r.index[key]; ok {
+		return
+	}
+	r.index[key] = len(r.keys)
+	r.keys = append(r.keys, key)
+}
+
+func (r *Random) Access(key string) {}
+
+// Remove 用最后一个 key 填补被删除的位置
+func (r *Random) Remove(key string) {
+	i, ok := r.index[key]
+	if !ok {
+		return
+	}
+	last := len(r.keys) - 1
+	r.keys[i] = r.keys[last]
+	r.index[r.keys[i]] = i
+	r.keys = r.keys[:last]
+	delete(r.index, key)
+}
+
+func (r *Random) Victim() (string, bool) {
+	if len(r.keys) == 0 {
+		return "", false
+	}
+	return r.keys[r.rand.Intn(len(r.keys))], true
+}
+
+func (r *Random) Len() int {
+	return len(r.keys)
+}
@@ -0,0 +1,23 @@
+package eviction
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRandom(t *testing.T) {
+	r := NewRandom()
+	_, ok := r.Victim()
+	assert.False(t, ok)
+
+	r.Add("k1")
+	r.Add("k2")
+	r.Add("k3")
+	r.Remove("k1")
+	assert.Equal(t, 2, r.Len())
+	for i := 0; i < 100; i++ {
+		victim, ok := r.Victim()
+		assert.True(t, ok)
+		assert.Contains(t, []string{"k2", "k3"}, victim)
+	}
+}
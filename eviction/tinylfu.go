@@ -0,0 +1,167 @@
+package eviction
+
+import "hash/fnv"
+
+var _ Policy = &WTinyLFU{}
+
+// WTinyLFU 新 key 先进入容量约 1% 的 window LRU，window 溢出的候选 key
+// 与 probation 段的淘汰者比较 count-min sketch 估算的访问频率，频率低者被淘汰。
+// 主区为 SLRU，probation 中再次被访问的 key 晋升到 protected
+type WTinyLFU struct {
+	sketch *countMinSketch
+
+	window    *keyList
+	probation *keyList
+	protected *keyList
+
+	windowCap    int
+	mainCap      int
+	protectedCap int
+}
+
+func NewWTinyLFU(capacity int) *WTinyLFU {
+	windowCap := maxInt(1, capacity/100)
+	mainCap := maxInt(1, capacity-windowCap)
+	return &WTinyLFU{
+		sketch:       newCountMinSketch(capacity),
+		window:       newKeyList(),
+		probation:    newKeyList(),
+		protected:    newKeyList(),
+		windowCap:    windowCap,
+		mainCap:      mainCap,
+		protectedCap: maxInt(1, mainCap*8/10),
+	}
+}
+
+func (w *WTinyLFU) Add(key string) {
+	if w.window.contains(key) || w.probation.contains(key) || w.protected.contains(key) {
+		w.Access(key)
+		return
+	}
+	w.sketch.increment(key)
+	w.window.pushBack(key)
+	w.rebalance()
+}
+
+func (w *WTinyLFU) Access(key string) {
+	w.sketch.increment(key)
+	switch {
+	case w.window.contains(key):
+		w.window.moveToBack(key)
+	case w.probation.contains(key):
+		w.probation.remove(key)
+		w.protected.pushBack(key)
+		if w.protected.len() > w.protectedCap {
+			demoted, _ := w.protected.popFront()
+			w.probation.pushBack(demoted)
+		}
+	case w.protected.contains(key):
+		w.protected.moveToBack(key)
+	}
+}
+
+func (w *WTinyLFU) Remove(key string) {
+	if !w.window.remove(key) && !w.probation.remove(key) {
+		w.protected.remove(key)
+	}
+	w.rebalance()
+}
+
+func (w *WTinyLFU) Victim() (string, bool) {
+	victim, ok := w.probation.front()
+	if !ok {
+		victim, ok = w.protected.front()
+	}
+	candidate, hasCandidate := w.window.front()
+	if !ok {
+		return candidate, hasCandidate
+	}
+	if !hasCandidate || w.window.len() <= w.windowCap {
+		return victim, true
+	}
+	if w.sketch.estimate(candidate) > w.sketch.estimate(victim) {
+		return victim, true
+	}
+	return candidate, true
+}
+
+func (w *WTinyLFU) Len() int {
+	return w.window.len() + w.probation.len() + w.protected.len()
+}
+
+// rebalance 主区未满时把 window 溢出的 key 直接放入 probation
+func (w *WTinyLFU) rebalance() {
+	for w.window.len() > w.windowCap && w.probation.len()+w.protected.len() < w.mainCap {
+		key, _ := w.window.popFront()
+		w.probation.pushBack(key)
+	}
+}
+
+const (
+	sketchDepth      = 4
+	sketchMaxCounter = 15
+)
+
+// countMinSketch 访问频率估算，累计次数达到 sampleSize 后所有计数减半以适应访问模式变化
+type countMinSketch struct {
+	counters   [sketchDepth][]uint8
+	mask       uint64
+	additions  int
+	sampleSize int
+}
+
+func newCountMinSketch(capacity int) *countMinSketch {
+	width := 16
+	for width < capacity {
+		width <<= 1
+	}
+	s := &countMinSketch{
+		mask:       uint64(width - 1),
+		sampleSize: maxInt(10*capacity, 16),
+	}
+	for i := range s.counters {
+		s.counters[i] = make([]uint8, width)
+	}
+	return s
+}
+
+func (s *countMinSketch) increment(key string) {
+	h1, h2 := hashKey(key)
+	for i := range s.counters {
+		idx := (h1 + uint64(i)*h2) & s.mask
+		if s.counters[i][idx] < sketchMaxCounter {
+			s.counters[i][idx]++
+		}
+	}
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.reset()
+	}
+}
+
+func (s *countMinSketch) estimate(key string) uint8 {
+	h1, h2 := hashKey(key)
+	res := uint8(sketchMaxCounter)
+	for i := range s.counters {
+		if c := s.counters[i][(h1+uint64(i)*h2)&s.mask]; c < res {
+			res = c
+		}
+	}
+	return res
+}
+
+func (s *countMinSketch) reset() {
+	for i := range s.counters {
+		for j := range s.counters[i] {
+			s.counters[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+func hashKey(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	sum := h.Sum64()
+	return sum, (sum >> 32) | 1
+}
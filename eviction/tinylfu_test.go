@@ -0,0 +1,73 @@
+package eviction
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func TestWTinyLFU(t *testing.T) {
+	w := NewWTinyLFU(100)
+	for i := 0; i < 99; i++ {
+		w.Add(fmt.Sprintf("hot%d", i))
+	}
+	for i := 0; i < 5; i++ {
+		w.Access("hot0")
+	}
+	w.Add("cold")
+	w.Add("cold2")
+	//window 已满，新来的低频 key 不能替换 probation 中的高频 key
+	victim, ok := w.Victim()
+	assert.True(t, ok)
+	assert.Equal(t, "cold", victim)
+	assert.Equal(t, 101, w.Len())
+
+	w.Remove(victim)
+	assert.Equal(t, 100, w.Len())
+}
+
+// TestHitRatio 在 zipf 分布的访问序列上比较各策略的命中率
+func TestHitRatio(t *testing.T) {
+	const capacity = 1000
+	policies := []struct {
+		name   string
+		policy Policy
+	}{
+		{name: "lru", policy: NewLRU()},
+		{name: "lfu", policy: NewLFU()},
+		{name: "fifo", policy: NewFIFO()},
+		{name: "random", policy: NewRandom()},
+		{name: "arc", policy: NewARC(capacity)},
+		{name: "w-tinylfu", policy: NewWTinyLFU(capacity)},
+	}
+	for _, p := range policies {
+		t.Run(p.name, func(t *testing.T) {
+			zipf := rand.NewZipf(rand.New(rand.NewSource(1)), 1.01, 1, 100000)
+			ratio := hitRatio(p.policy, capacity, zipf, 200000)
+			t.Logf("%s hit ratio: %.4f", p.name, ratio)
+			assert.Greater(t, ratio, 0.2)
+		})
+	}
+}
+
+func hitRatio(p Policy, capacity int, zipf *rand.Zipf, n int) float64 {
+	resident := make(map[string]struct{}, capacity)
+	hits := 0
+	for i := 0; i < n; i++ {
+		key := fmt.Sprint(zipf.Uint64())
+		if _, ok := resident[key]; ok {
+			hits++
+			p.Access(key)
+			continue
+		}
+		resident[key] = struct{}{}
+		p.Add(key)
+		for p.Len() > capacity {
+			victim, _ := p.Victim()
+			p.Remove(victim)
+			delete(resident, victim)
+		}
+	}
+	return float64(hits) / float64(n)
+}
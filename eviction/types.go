@@ -0,0 +1,15 @@
+package eviction
+
+// Policy 淘汰策略，只负责记录 key 的写入与访问并给出淘汰建议，
+// 真正的删除由缓存完成后再调用 Remove。实现均非并发安全，由缓存加锁保护
+type Policy interface {
+	// Add 记录新写入的 key
+	Add(key string)
+	// Access 记录一次对已有 key 的访问
+	Access(key string)
+	// Remove 移除 key，key 不存在时忽略
+	Remove(key string)
+	// Victim 返回下一个应被淘汰的 key，没有可淘汰的 key 时返回 false
+	Victim() (string, bool)
+	Len() int
+}
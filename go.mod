@@ -3,7 +3,6 @@ module github.com/ac-zht/cache
 go 1.18
 
 require (
-	github.com/ac-zht/gotools v1.0.7
 	github.com/stretchr/testify v1.8.4
 	golang.org/x/sync v0.4.0
 )
@@ -0,0 +1,123 @@
+package async
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Wait 等待 wg 归零，ctx 结束后不再等待
+func Wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Retry fn 失败后最多重试 maxRetry 次，首次重试前等待 backoff，之后每次翻倍；
+// retryable 不为空且返回 false 的错误不重试，ctx 结束后不再重试，返回最后一次的错误
+func Retry(ctx context.Context, maxRetry int, backoff time.Duration,
+	fn func(ctx context.Context) error, retryable func(err error) bool) error {
+	err := fn(ctx)
+	for i := 0; err != nil && i < maxRetry; i++ {
+		if retryable != nil && !retryable(err) {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+		err = fn(ctx)
+	}
+	return err
+}
+
+// Tracker 跟踪一个缓存提交的异步任务，Close 后拒绝新任务，Release 标记底层资源已释放。
+// 与 sync.WaitGroup 不同，Add 可以与 Wait 并发调用
+type Tracker struct {
+	mutex *sync.Mutex
+	n     int
+	//idle 在没有任务时处于关闭状态
+	idle     chan struct{}
+	closed   bool
+	released bool
+}
+
+func NewTracker() *Tracker {
+	idle := make(chan struct{})
+	close(idle)
+	return &Tracker{
+		mutex: &sync.Mutex{},
+		idle:  idle,
+	}
+}
+
+// Add 登记一个任务，已关闭时返回 false
+func (t *Tracker) Add() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.closed {
+		return false
+	}
+	if t.n == 0 {
+		t.idle = make(chan struct{})
+	}
+	t.n++
+	return true
+}
+
+func (t *Tracker) Done() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.n--
+	if t.n == 0 {
+		close(t.idle)
+	}
+}
+
+// Wait 等待已登记的任务全部完成，ctx 结束后不再等待
+func (t *Tracker) Wait(ctx context.Context) error {
+	t.mutex.Lock()
+	idle := t.idle
+	t.mutex.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *Tracker) Closed() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.closed
+}
+
+// Close 拒绝新任务，资源已释放时返回 false
+func (t *Tracker) Close() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.closed = true
+	return !t.released
+}
+
+// Release 标记资源已释放，只有第一次调用返回 true
+func (t *Tracker) Release() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.released {
+		return false
+	}
+	t.released = true
+	return true
+}
@@ -0,0 +1,96 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	errFatal := errors.New("fatal")
+	testCases := []struct {
+		name      string
+		failures  int
+		err       error
+		retryable func(err error) bool
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "success", wantCalls: 1},
+		{name: "retry then success", failures: 2, err: errors.New("transient"), wantCalls: 3},
+		{name: "exceed max retry", failures: 5, err: errFatal, wantCalls: 4, wantErr: errFatal},
+		{
+			name:      "not retryable",
+			failures:  5,
+			err:       errFatal,
+			retryable: func(err error) bool { return !errors.Is(err, errFatal) },
+			wantCalls: 1,
+			wantErr:   errFatal,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			err := Retry(context.Background(), 3, time.Millisecond, func(ctx context.Context) error {
+				calls++
+				if calls <= tc.failures {
+					return tc.err
+				}
+				return nil
+			}, tc.retryable)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantCalls, calls)
+		})
+	}
+}
+
+func TestWait(t *testing.T) {
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, Wait(ctx, wg))
+	wg.Done()
+	assert.NoError(t, Wait(context.Background(), wg))
+}
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker()
+	assert.NoError(t, tracker.Wait(context.Background()))
+	assert.True(t, tracker.Add())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, tracker.Wait(ctx))
+
+	//关闭后拒绝新任务，等待失败时可以再次关闭
+	assert.True(t, tracker.Close())
+	assert.True(t, tracker.Closed())
+	assert.False(t, tracker.Add())
+	assert.True(t, tracker.Close())
+	tracker.Done()
+	assert.NoError(t, tracker.Wait(context.Background()))
+	assert.True(t, tracker.Release())
+	assert.False(t, tracker.Release())
+	assert.False(t, tracker.Close())
+}
+
+func TestTracker_Concurrent(t *testing.T) {
+	tracker := NewTracker()
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if tracker.Add() {
+				tracker.Done()
+			}
+			_ = tracker.Wait(context.Background())
+		}()
+	}
+	tracker.Close()
+	wg.Wait()
+	assert.NoError(t, tracker.Wait(context.Background()))
+}
@@ -0,0 +1,81 @@
+package invalidation
+
+import (
+	"context"
+	"sync"
+)
+
+// Message 一次失效通知，Origin 为发布方的 ID，订阅方据此忽略自己发出的通知
+type Message struct {
+	Origin string
+	Key    string
+}
+
+// Broadcaster 在多个实例之间广播 key 失效通知
+type Broadcaster interface {
+	Publish(ctx context.Context, msg Message) error
+	// Subscribe 注册通知回调，返回取消订阅的函数
+	Subscribe(fn func(msg Message)) func()
+}
+
+var _ Broadcaster = &MemoryBroadcaster{}
+
+// subscribers 回调列表，MemoryBroadcaster 和 TCPBroadcaster 共用
+type subscribers struct {
+	mutex *sync.RWMutex
+	fns   map[int]func(msg Message)
+	next  int
+}
+
+func newSubscribers() *subscribers {
+	return &subscribers{
+		mutex: &sync.RWMutex{},
+		fns:   map[int]func(msg Message){},
+	}
+}
+
+func (s *subscribers) Subscribe(fn func(msg Message)) func() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	id := s.next
+	s.next++
+	s.fns[id] = fn
+	return func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		delete(s.fns, id)
+	}
+}
+
+// dispatch 回调在锁外执行，回调中可以再次发布或取消订阅
+func (s *subscribers) dispatch(msg Message) {
+	s.mutex.RLock()
+	fns := make([]func(msg Message), 0, len(s.fns))
+	for _, fn := range s.fns {
+		fns = append(fns, fn)
+	}
+	s.mutex.RUnlock()
+	for _, fn := range fns {
+		fn(msg)
+	}
+}
+
+// MemoryBroadcaster 进程内广播，同一进程的多个缓存实例共享一个 MemoryBroadcaster，
+// 通知同步投递给所有订阅方
+type MemoryBroadcaster struct {
+	*subscribers
+}
+
+func NewMemoryBroadcaster() *MemoryBroadcaster {
+	return &MemoryBroadcaster{
+		subscribers: newSubscribers(),
+	}
+}
+
+func (b *MemoryBroadcaster) Publish(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.dispatch(msg)
+	return nil
+}
@@ -0,0 +1,29 @@
+package invalidation
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMemoryBroadcaster_Publish(t *testing.T) {
+	b := NewMemoryBroadcaster()
+	var got1, got2 []Message
+	cancel1 := b.Subscribe(func(msg Message) {
+		got1 = append(got1, msg)
+	})
+	b.Subscribe(func(msg Message) {
+		got2 = append(got2, msg)
+	})
+
+	assert.NoError(t, b.Publish(context.Background(), Message{Origin: "a", Key: "k1"}))
+	cancel1()
+	assert.NoError(t, b.Publish(context.Background(), Message{Origin: "a", Key: "k2"}))
+
+	assert.Equal(t, []Message{{Origin: "a", Key: "k1"}}, got1)
+	assert.Equal(t, []Message{{Origin: "a", Key: "k1"}, {Origin: "a", Key: "k2"}}, got2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, b.Publish(ctx, Message{Key: "k3"}))
+}
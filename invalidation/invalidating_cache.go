@@ -0,0 +1,100 @@
+package invalidation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/ac-zht/cache"
+	"time"
+)
+
+var _ cache.Cache = &InvalidatingCache{}
+
+type InvalidatingCacheOption func(cache *InvalidatingCache)
+
+// InvalidatingCache 本地缓存装饰器，Set、Delete 和 LoadAndDelete 成功后广播 key 失效通知，
+// 收到其他实例的通知时删除本地的 key，删除不会再次广播
+type InvalidatingCache struct {
+	cache.Cache
+	id          string
+	broadcaster Broadcaster
+	unsubscribe func()
+}
+
+func NewInvalidatingCache(c cache.Cache, broadcaster Broadcaster, opts ...InvalidatingCacheOption) *InvalidatingCache {
+	res := &InvalidatingCache{
+		Cache:       c,
+		id:          newID(),
+		broadcaster: broadcaster,
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+	res.unsubscribe = broadcaster.Subscribe(res.onMessage)
+	return res
+}
+
+// InvalidatingCacheWithID 指定实例 ID，默认随机生成，同一广播内的实例 ID 必须不同
+func InvalidatingCacheWithID(id string) InvalidatingCacheOption {
+	return func(cache *InvalidatingCache) {
+		cache.id = id
+	}
+}
+
+func (c *InvalidatingCache) ID() string {
+	return c.id
+}
+
+// Set 本地写入成功后广播，广播失败时返回错误，此时其他实例可能仍持有旧值
+func (c *InvalidatingCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
+	if err := c.Cache.Set(ctx, key, val, expiration); err != nil {
+		return err
+	}
+	return c.publish(ctx, key)
+}
+
+func (c *InvalidatingCache) Delete(ctx context.Context, key string) error {
+	if err := c.Cache.Delete(ctx, key); err != nil {
+		return err
+	}
+	return c.publish(ctx, key)
+}
+
+// LoadAndDelete 本地不存在 key 时也会广播，其他实例可能仍有该 key
+func (c *InvalidatingCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	val, err := c.Cache.LoadAndDelete(ctx, key)
+	if err != nil && !errors.Is(err, cache.ErrKeyNotFound) {
+		return nil, err
+	}
+	if e := c.publish(ctx, key); e != nil {
+		return val, e
+	}
+	return val, err
+}
+
+func (c *InvalidatingCache) publish(ctx context.Context, key string) error {
+	return c.broadcaster.Publish(ctx, Message{Origin: c.id, Key: key})
+}
+
+func (c *InvalidatingCache) onMessage(msg Message) {
+	if msg.Origin == c.id {
+		return
+	}
+	_ = c.Cache.Delete(context.Background(), msg.Key)
+}
+
+// Close 取消订阅，底层缓存实现了 cache.Closer 时一并关闭，Broadcaster 由调用方关闭
+func (c *InvalidatingCache) Close(ctx context.Context) error {
+	c.unsubscribe()
+	if closer, ok := c.Cache.(cache.Closer); ok {
+		return closer.Close(ctx)
+	}
+	return nil
+}
+
+func newID() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,94 @@
+package invalidation
+
+import (
+	"context"
+	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/local_cache"
+	"github.com/ac-zht/cache/write_through"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestInvalidatingCache(t *testing.T) {
+	testCase := []struct {
+		name   string
+		action func(c *InvalidatingCache) error
+	}{
+		{
+			name: "set",
+			action: func(c *InvalidatingCache) error {
+				return c.Set(context.Background(), "key", []byte("new"), time.Minute)
+			},
+		},
+		{
+			name: "delete",
+			action: func(c *InvalidatingCache) error {
+				return c.Delete(context.Background(), "key")
+			},
+		},
+		{
+			name: "load and delete",
+			action: func(c *InvalidatingCache) error {
+				_, err := c.LoadAndDelete(context.Background(), "key")
+				return err
+			},
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			bus := NewMemoryBroadcaster()
+			l1, l2 := local_cache.NewBytesCache(10), local_cache.NewBytesCache(10)
+			c1 := NewInvalidatingCache(l1, bus, InvalidatingCacheWithID("1"))
+			c2 := NewInvalidatingCache(l2, bus, InvalidatingCacheWithID("2"))
+			require.NoError(t, l1.Set(context.Background(), "key", []byte("old"), time.Minute))
+			require.NoError(t, l2.Set(context.Background(), "key", []byte("old"), time.Minute))
+
+			require.NoError(t, tc.action(c1))
+			_, err := c2.Get(context.Background(), "key")
+			assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+			//发布方自身的写入不受通知影响
+			if tc.name == "set" {
+				val, err := c1.Get(context.Background(), "key")
+				require.NoError(t, err)
+				assert.Equal(t, []byte("new"), val)
+			}
+		})
+	}
+}
+
+func TestInvalidatingCache_WriteThrough(t *testing.T) {
+	b1, err := NewTCPBroadcaster("127.0.0.1:0", nil)
+	require.NoError(t, err)
+	b2, err := NewTCPBroadcaster("127.0.0.1:0", []string{b1.Addr().String()})
+	require.NoError(t, err)
+	b1.AddPeer(b2.Addr().String())
+	defer func() {
+		_ = b1.Close(context.Background())
+		_ = b2.Close(context.Background())
+	}()
+
+	db := map[string][]byte{}
+	storeFunc := func(ctx context.Context, key string, val []byte) error {
+		db[key] = val
+		return nil
+	}
+	l1, l2 := local_cache.NewBytesCache(10), local_cache.NewBytesCache(10)
+	w1 := write_through.NewWriteThroughCache(NewInvalidatingCache(l1, b1), storeFunc)
+	c2 := NewInvalidatingCache(l2, b2)
+	require.NoError(t, l2.Set(context.Background(), "key", []byte("old"), time.Minute))
+
+	require.NoError(t, w1.Set(context.Background(), "key", []byte("new"), time.Minute))
+	assert.Eventually(t, func() bool {
+		_, err := c2.Get(context.Background(), "key")
+		return err != nil
+	}, time.Second, time.Millisecond*10)
+	val, err := w1.Get(context.Background(), "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("new"), val)
+	assert.Equal(t, []byte("new"), db["key"])
+
+	require.NoError(t, c2.Close(context.Background()))
+	require.NoError(t, w1.Close(context.Background()))
+}
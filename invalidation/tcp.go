@@ -0,0 +1,303 @@
+package invalidation
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/ac-zht/cache"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+var _ Broadcaster = &TCPBroadcaster{}
+
+// maxFrameSize 单个通知的最大长度，超过时认为对端数据有误并断开连接
+const maxFrameSize = 1 << 20
+
+type TCPBroadcasterOption func(b *TCPBroadcaster)
+
+// TCPBroadcaster 点对点广播，每个实例监听一个地址并主动连接所有 peer，
+// Publish 并发地把通知写到每个 peer 的连接上，连接断开时在下一次 Publish 重连
+type TCPBroadcaster struct {
+	*subscribers
+	ln           net.Listener
+	dialTimeout  time.Duration
+	writeTimeout time.Duration
+	dialContext  func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	//mutex 只保护 peers、accepted 和 closed，连接由每个 peer 自己的锁保护，拨号和写入时不持有 mutex
+	mutex    *sync.Mutex
+	peers    []*tcpPeer
+	accepted map[net.Conn]struct{}
+	closed   bool
+	//done Close 时关闭，取消进行中的拨号
+	done chan struct{}
+	wg   *sync.WaitGroup
+}
+
+// tcpPeer 到一个 peer 的连接，同一个 peer 的写入串行执行
+type tcpPeer struct {
+	addr   string
+	mutex  *sync.Mutex
+	conn   net.Conn
+	closed bool
+}
+
+// NewTCPBroadcaster 监听 addr 并接收 peer 发来的通知，addr 端口为 0 时由系统分配，可通过 Addr 获取
+func NewTCPBroadcaster(addr string, peers []string, opts ...TCPBroadcasterOption) (*TCPBroadcaster, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	res := &TCPBroadcaster{
+		subscribers:  newSubscribers(),
+		ln:           ln,
+		dialTimeout:  time.Second * 3,
+		writeTimeout: time.Second * 3,
+		mutex:        &sync.Mutex{},
+		accepted:     map[net.Conn]struct{}{},
+		done:         make(chan struct{}),
+		wg:           &sync.WaitGroup{},
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+	res.dialContext = (&net.Dialer{Timeout: res.dialTimeout}).DialContext
+	for _, peer := range peers {
+		res.AddPeer(peer)
+	}
+	res.wg.Add(1)
+	go res.serve()
+	return res, nil
+}
+
+func TCPBroadcasterWithDialTimeout(timeout time.Duration) TCPBroadcasterOption {
+	return func(b *TCPBroadcaster) {
+		b.dialTimeout = timeout
+	}
+}
+
+// TCPBroadcasterWithWriteTimeout Publish 的 ctx 没有截止时间时写入单个 peer 的超时时间，默认 3 秒
+func TCPBroadcasterWithWriteTimeout(timeout time.Duration) TCPBroadcasterOption {
+	return func(b *TCPBroadcaster) {
+		b.writeTimeout = timeout
+	}
+}
+
+func (b *TCPBroadcaster) Addr() net.Addr {
+	return b.ln.Addr()
+}
+
+// AddPeer 新增一个 peer，已存在时忽略
+func (b *TCPBroadcaster) AddPeer(addr string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for _, p := range b.peers {
+		if p.addr == addr {
+			return
+		}
+	}
+	b.peers = append(b.peers, &tcpPeer{addr: addr, mutex: &sync.Mutex{}})
+}
+
+// Publish 并发发送给所有 peer，某个 peer 失败或不可达不影响其他 peer，返回第一个错误
+func (b *TCPBroadcaster) Publish(ctx context.Context, msg Message) error {
+	frame, err := encodeFrame(msg)
+	if err != nil {
+		return err
+	}
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return cache.ErrClosed
+	}
+	peers := append([]*tcpPeer(nil), b.peers...)
+	b.mutex.Unlock()
+
+	errs := make([]error, len(peers))
+	wg := &sync.WaitGroup{}
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(i int, peer *tcpPeer) {
+			defer wg.Done()
+			errs[i] = b.send(ctx, peer, frame)
+		}(i, peer)
+	}
+	wg.Wait()
+	for _, err = range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send 使用已有连接写入失败时重连一次
+func (b *TCPBroadcaster) send(ctx context.Context, peer *tcpPeer, frame []byte) error {
+	peer.mutex.Lock()
+	defer peer.mutex.Unlock()
+	for retry := 0; ; retry++ {
+		if peer.closed {
+			return cache.ErrClosed
+		}
+		conn, ok := peer.conn, peer.conn != nil
+		if !ok {
+			var err error
+			if conn, err = b.dial(ctx, peer.addr); err != nil {
+				return err
+			}
+			peer.conn = conn
+		}
+		dl, hasDeadline := ctx.Deadline()
+		if !hasDeadline {
+			dl = time.Now().Add(b.writeTimeout)
+		}
+		err := conn.SetWriteDeadline(dl)
+		if err == nil {
+			_, err = conn.Write(frame)
+		}
+		if err == nil {
+			return nil
+		}
+		_ = conn.Close()
+		peer.conn = nil
+		if !ok || retry > 0 {
+			return err
+		}
+	}
+}
+
+// dial Close 时取消进行中的拨号
+func (b *TCPBroadcaster) dial(ctx context.Context, addr string) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-b.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return b.dialContext(ctx, "tcp", addr)
+}
+
+func (b *TCPBroadcaster) serve() {
+	defer b.wg.Done()
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+		b.mutex.Lock()
+		if b.closed {
+			b.mutex.Unlock()
+			_ = conn.Close()
+			return
+		}
+		b.accepted[conn] = struct{}{}
+		b.wg.Add(1)
+		b.mutex.Unlock()
+		go b.handle(conn)
+	}
+}
+
+func (b *TCPBroadcaster) handle(conn net.Conn) {
+	defer b.wg.Done()
+	defer func() {
+		b.mutex.Lock()
+		delete(b.accepted, conn)
+		b.mutex.Unlock()
+		_ = conn.Close()
+	}()
+	r := bufio.NewReader(conn)
+	for {
+		msg, err := decodeFrame(r)
+		if err != nil {
+			return
+		}
+		b.dispatch(msg)
+	}
+}
+
+// Close 关闭监听和所有连接，等待接收 goroutine 退出，ctx 结束后不再等待
+func (b *TCPBroadcaster) Close(ctx context.Context) error {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return cache.ErrClosed
+	}
+	b.closed = true
+	close(b.done)
+	err := b.ln.Close()
+	for conn := range b.accepted {
+		_ = conn.Close()
+	}
+	peers := b.peers
+	b.mutex.Unlock()
+	//拨号已被取消，写入有超时，等待每个 peer 当前的发送结束后关闭连接
+	for _, peer := range peers {
+		peer.mutex.Lock()
+		peer.closed = true
+		if peer.conn != nil {
+			_ = peer.conn.Close()
+			peer.conn = nil
+		}
+		peer.mutex.Unlock()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// encodeFrame 帧格式：4 字节总长度 | 2 字节 origin 长度 | origin | key
+func encodeFrame(msg Message) ([]byte, error) {
+	if len(msg.Origin) > 0xffff {
+		return nil, errors.New("invalidation: origin too long")
+	}
+	size := 2 + len(msg.Origin) + len(msg.Key)
+	if size > maxFrameSize {
+		return nil, errors.New("invalidation: key too long")
+	}
+	buf := make([]byte, 4+size)
+	binary.BigEndian.PutUint32(buf, uint32(size))
+	binary.BigEndian.PutUint16(buf[4:], uint16(len(msg.Origin)))
+	copy(buf[6:], msg.Origin)
+	copy(buf[6+len(msg.Origin):], msg.Key)
+	return buf, nil
+}
+
+func decodeFrame(r io.Reader) (Message, error) {
+	var head [4]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return Message{}, err
+	}
+	size := binary.BigEndian.Uint32(head[:])
+	if size < 2 || size > maxFrameSize {
+		return Message{}, fmt.Errorf("invalidation: invalid frame size %d", size)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return Message{}, err
+	}
+	n := int(binary.BigEndian.Uint16(body))
+	if 2+n > len(body) {
+		return Message{}, fmt.Errorf("invalidation: invalid origin size %d", n)
+	}
+	return Message{
+		Origin: string(body[2 : 2+n]),
+		Key:    string(body[2+n:]),
+	}, nil
+}
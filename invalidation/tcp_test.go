@@ -0,0 +1,120 @@
+package invalidation
+
+import (
+	"bytes"
+	"context"
+	"github.com/ac-zht/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFrame(t *testing.T) {
+	testCase := []struct {
+		name string
+		msg  Message
+	}{
+		{name: "normal", msg: Message{Origin: "node-1", Key: "user:1"}},
+		{name: "empty origin", msg: Message{Key: "key"}},
+		{name: "key with separators", msg: Message{Origin: "o", Key: "a b\r\nc"}},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			frame, err := encodeFrame(tc.msg)
+			require.NoError(t, err)
+			msg, err := decodeFrame(bytes.NewReader(frame))
+			require.NoError(t, err)
+			assert.Equal(t, tc.msg, msg)
+		})
+	}
+}
+
+type recorder struct {
+	mutex *sync.Mutex
+	msgs  []Message
+}
+
+func newRecorder(b Broadcaster) *recorder {
+	r := &recorder{mutex: &sync.Mutex{}}
+	b.Subscribe(func(msg Message) {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		r.msgs = append(r.msgs, msg)
+	})
+	return r
+}
+
+func (r *recorder) get() []Message {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]Message(nil), r.msgs...)
+}
+
+func TestTCPBroadcaster_Publish(t *testing.T) {
+	b1, err := NewTCPBroadcaster("127.0.0.1:0", nil)
+	require.NoError(t, err)
+	b2, err := NewTCPBroadcaster("127.0.0.1:0", []string{b1.Addr().String()})
+	require.NoError(t, err)
+	b3, err := NewTCPBroadcaster("127.0.0.1:0", []string{b1.Addr().String()})
+	require.NoError(t, err)
+	b1.AddPeer(b2.Addr().String())
+	b1.AddPeer(b3.Addr().String())
+	r1, r2, r3 := newRecorder(b1), newRecorder(b2), newRecorder(b3)
+
+	require.NoError(t, b1.Publish(context.Background(), Message{Origin: "1", Key: "k1"}))
+	require.NoError(t, b2.Publish(context.Background(), Message{Origin: "2", Key: "k2"}))
+	assert.Eventually(t, func() bool {
+		return len(r2.get()) == 1 && len(r3.get()) == 1 && len(r1.get()) == 1
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, []Message{{Origin: "2", Key: "k2"}}, r1.get())
+	assert.Equal(t, []Message{{Origin: "1", Key: "k1"}}, r2.get())
+	assert.Equal(t, []Message{{Origin: "1", Key: "k1"}}, r3.get())
+
+	//b3 关闭后其他 peer 不受影响，写入已断开的连接不一定立即报错
+	require.NoError(t, b3.Close(context.Background()))
+	_ = b1.Publish(context.Background(), Message{Origin: "1", Key: "k3"})
+	assert.Eventually(t, func() bool {
+		return len(r2.get()) == 2
+	}, time.Second, time.Millisecond*10)
+
+	require.NoError(t, b1.Close(context.Background()))
+	require.NoError(t, b2.Close(context.Background()))
+	assert.Equal(t, cache.ErrClosed, b1.Publish(context.Background(), Message{Key: "k4"}))
+	assert.Equal(t, cache.ErrClosed, b1.Close(context.Background()))
+}
+
+func TestTCPBroadcaster_SlowPeer(t *testing.T) {
+	b1, err := NewTCPBroadcaster("127.0.0.1:0", nil)
+	require.NoError(t, err)
+	defer b1.Close(context.Background())
+	r1 := newRecorder(b1)
+	b2, err := NewTCPBroadcaster("127.0.0.1:0", []string{"slow:1", b1.Addr().String()})
+	require.NoError(t, err)
+	//连接 slow:1 一直阻塞到 ctx 结束
+	dial := b2.dialContext
+	b2.dialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr == "slow:1" {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return dial(ctx, network, addr)
+	}
+
+	published := make(chan error, 1)
+	go func() {
+		published <- b2.Publish(context.Background(), Message{Origin: "2", Key: "k1"})
+	}()
+	//不可达的 peer 不影响其他 peer 收到通知
+	assert.Eventually(t, func() bool {
+		return len(r1.get()) == 1
+	}, time.Second, time.Millisecond*10)
+
+	//Close 取消进行中的拨号，不等待 dialTimeout
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, b2.Close(ctx))
+	assert.ErrorIs(t, <-published, context.Canceled)
+}
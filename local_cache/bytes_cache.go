@@ -0,0 +1,115 @@
+package local_cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ac-zht/cache"
+	"time"
+)
+
+var _ cache.BatchCache = &BytesCache{}
+
+// ErrUnexpectedType 通过 BuildInMapCache 的方法写入了不是 []byte 的值
+var ErrUnexpectedType = errors.New("cache: value is not []byte")
+
+// BytesCache 基于 BuildInMapCache 的 []byte 缓存，实现 cache.Cache 接口，
+// 可以直接作为 max_memory_cache、read_through、write_through 等装饰器的底层存储
+type BytesCache struct {
+	*BuildInMapCache
+}
+
+func NewBytesCache(cap int, opts ...BuildInMapCacheOption) *BytesCache {
+	res := &BytesCache{
+		BuildInMapCache: NewBuildInMapCache(cap, opts...),
+	}
+	res.size = func(val any) int64 {
+		b, _ := val.([]byte)
+		return bytesSize(b)
+	}
+	return res
+}
+
+func bytesSize(val []byte) int64 {
+	return int64(len(val))
+}
+
+func (c *BytesCache) Get(ctx context.Context, key string) ([]byte, error) {
+	val, err := c.BuildInMapCache.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return toBytes(key, val)
+}
+
+func (c *BytesCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
+	return c.BuildInMapCache.Set(ctx, key, val, expiration)
+}
+
+func (c *BytesCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	val, err := c.BuildInMapCache.LoadAndDelete(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return toBytes(key, val)
+}
+
+func (c *BytesCache) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	vals, err := c.BuildInMapCache.GetMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string][]byte, len(vals))
+	for key, val := range vals {
+		if res[key], err = toBytes(key, val); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (c *BytesCache) SetMulti(ctx context.Context, vals map[string][]byte, expiration time.Duration) error {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+	if c.closed {
+		return cache.ErrClosed
+	}
+	for key, val := range vals {
+		c.set(key, val, expiration)
+	}
+	return nil
+}
+
+// AddEvictedHook 追加一个淘汰回调，不会被 OnEvicted、OnEvictedWithReason 覆盖，覆盖 BuildInMapCache.AddEvictedHook
+func (c *BytesCache) AddEvictedHook(fn func(key string, val []byte, reason cache.EvictReason)) {
+	c.BuildInMapCache.AddEvictedHook(func(key string, val any, reason cache.EvictReason) {
+		b, _ := val.([]byte)
+		fn(key, b, reason)
+	})
+}
+
+// OnEvictedWithReason 注册带移除原因的淘汰回调，覆盖 BuildInMapCache.OnEvictedWithReason
+func (c *BytesCache) OnEvictedWithReason(fn func(key string, val []byte, reason cache.EvictReason)) {
+	c.BuildInMapCache.OnEvictedWithReason(func(key string, val any, reason cache.EvictReason) {
+		b, _ := val.([]byte)
+		fn(key, b, reason)
+	})
+}
+
+// OnEvicted 注册淘汰回调，覆盖 BuildInMapCache.OnEvicted
+func (c *BytesCache) OnEvicted(fn func(key string, val []byte)) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+	c.BuildInMapCache.OnEvicted = func(key string, val any) {
+		b, _ := val.([]byte)
+		fn(key, b)
+	}
+}
+
+func toBytes(key string, val any) ([]byte, error) {
+	b, ok := val.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w, key: %s, type: %T", ErrUnexpectedType, key, val)
+	}
+	return b, nil
+}
@@ -0,0 +1,187 @@
+package local_cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/clock/fake_clock"
+	"github.com/ac-zht/cache/max_memory_cache"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBytesCache_Get(t *testing.T) {
+	testCase := []struct {
+		name      string
+		cache     func() *BytesCache
+		key       string
+		wantVal   []byte
+		wantError error
+	}{
+		{
+			name: "get",
+			cache: func() *BytesCache {
+				res := NewBytesCache(10)
+				_ = res.Set(context.Background(), "key", []byte("value"), time.Minute)
+				return res
+			},
+			key:     "key",
+			wantVal: []byte("value"),
+		},
+		{
+			name: "not exist",
+			cache: func() *BytesCache {
+				return NewBytesCache(10)
+			},
+			key:       "key",
+			wantError: fmt.Errorf("%w, key: %s", cache.ErrKeyNotFound, "key"),
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cache()
+			val, err := c.Get(context.Background(), tc.key)
+			assert.Equal(t, tc.wantError, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantVal, val)
+		})
+	}
+}
+
+func TestBytesCache_LoadAndDelete(t *testing.T) {
+	testCase := []struct {
+		name        string
+		cache       func() *BytesCache
+		key         string
+		wantVal     []byte
+		wantEvicted map[string][]byte
+		wantError   error
+	}{
+		{
+			name: "deleted",
+			cache: func() *BytesCache {
+				res := NewBytesCache(10)
+				_ = res.Set(context.Background(), "key", []byte("value"), time.Minute)
+				return res
+			},
+			key:         "key",
+			wantVal:     []byte("value"),
+			wantEvicted: map[string][]byte{"key": []byte("value")},
+		},
+		{
+			name: "not exist",
+			cache: func() *BytesCache {
+				return NewBytesCache(10)
+			},
+			key:         "key",
+			wantEvicted: map[string][]byte{},
+			wantError:   fmt.Errorf("%w, key: %s", cache.ErrKeyNotFound, "key"),
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cache()
+			evicted := map[string][]byte{}
+			c.OnEvicted(func(key string, val []byte) {
+				evicted[key] = val
+			})
+			val, err := c.LoadAndDelete(context.Background(), tc.key)
+			assert.Equal(t, tc.wantError, err)
+			assert.Equal(t, tc.wantEvicted, evicted)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantVal, val)
+		})
+	}
+}
+
+func TestBytesCache_WithMaxMemoryCache(t *testing.T) {
+	var c cache.Cache = NewBytesCache(10)
+	m := max_memory_cache.NewMaxMemoryCache(10, c)
+	ctx := context.Background()
+	assert.NoError(t, m.Set(ctx, "k1", []byte("value1"), time.Minute))
+	assert.NoError(t, m.Set(ctx, "k2", []byte("value2"), time.Minute))
+
+	_, err := c.Get(ctx, "k1")
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+	val, err := m.Get(ctx, "k2")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value2"), val)
+}
+
+func TestBytesCache_Multi(t *testing.T) {
+	c := NewBytesCache(10)
+	var _ cache.BatchCache = c
+	err := c.SetMulti(context.Background(), map[string][]byte{
+		"k1": []byte("v1"),
+		"k2": []byte("v2"),
+	}, time.Minute)
+	assert.NoError(t, err)
+
+	vals, err := c.GetMulti(context.Background(), []string{"k1", "k2", "k3"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"k1": []byte("v1"), "k2": []byte("v2")}, vals)
+
+	assert.NoError(t, c.DeleteMulti(context.Background(), []string{"k1", "k3"}))
+	vals, err = c.GetMulti(context.Background(), []string{"k1", "k2"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"k2": []byte("v2")}, vals)
+}
+
+func TestBytesCache_Stats(t *testing.T) {
+	clk := fake_clock.NewFakeClock(time.Now())
+	c := NewBytesCache(10, BuildInMapCacheWithClock(clk))
+	ctx := context.Background()
+	assert.NoError(t, c.Set(ctx, "k1", []byte("value"), time.Minute))
+	assert.NoError(t, c.Set(ctx, "k2", []byte("v2"), time.Second))
+	clk.Advance(time.Second * 2)
+	assert.NoError(t, c.Set(ctx, "k1", []byte("v1"), time.Minute))
+	_, _ = c.Get(ctx, "k1")
+	_, _ = c.Get(ctx, "k2")
+	_, _ = c.Get(ctx, "k3")
+	assert.Equal(t, int64(2), c.Stats().Bytes)
+	assert.NoError(t, c.Delete(ctx, "k1"))
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(2), stats.Misses)
+	assert.Equal(t, uint64(3), stats.Sets)
+	assert.Equal(t, uint64(1), stats.Deletes)
+	assert.Equal(t, map[cache.EvictReason]uint64{
+		cache.EvictReasonDeleted: 1,
+		cache.EvictReasonExpired: 1,
+	}, stats.Evictions)
+	assert.Equal(t, int64(0), stats.Bytes)
+}
+
+func TestBytesCache_AddEvictedHook(t *testing.T) {
+	c := NewBytesCache(10)
+	ctx := context.Background()
+	var hooked, evicted []string
+	c.AddEvictedHook(func(key string, val []byte, reason cache.EvictReason) {
+		hooked = append(hooked, key+":"+string(val))
+	})
+	c.OnEvicted(func(key string, val []byte) {
+		evicted = append(evicted, key)
+	})
+	assert.NoError(t, c.Set(ctx, "k1", []byte("v1"), time.Minute))
+	assert.NoError(t, c.Delete(ctx, "k1"))
+	assert.Equal(t, []string{"k1:v1"}, hooked)
+	assert.Equal(t, []string{"k1"}, evicted)
+}
+
+func TestBytesCache_UnexpectedType(t *testing.T) {
+	c := NewBytesCache(10)
+	ctx := context.Background()
+	assert.NoError(t, c.BuildInMapCache.Set(ctx, "key", "value", time.Minute))
+	_, err := c.Get(ctx, "key")
+	assert.ErrorIs(t, err, ErrUnexpectedType)
+	_, err = c.GetMulti(ctx, []string{"key"})
+	assert.ErrorIs(t, err, ErrUnexpectedType)
+	_, err = c.LoadAndDelete(ctx, "key")
+	assert.ErrorIs(t, err, ErrUnexpectedType)
+}
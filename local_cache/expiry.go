@@ -0,0 +1,55 @@
+package local_cache
+
+import "container/heap"
+
+// expiryHeap 按过期时间排序的最小堆，堆中只保存设置了过期时间的缓存项，
+// item.index 记录其在堆中的位置，便于覆盖写入和删除时 O(log n) 移除
+type expiryHeap[K comparable, V any] []*item[K, V]
+
+func (h expiryHeap[K, V]) Len() int {
+	return len(h)
+}
+
+func (h expiryHeap[K, V]) Less(i, j int) bool {
+	return h[i].deadline.Before(h[j].deadline)
+}
+
+func (h expiryHeap[K, V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap[K, V]) Push(x any) {
+	itm := x.(*item[K, V])
+	itm.index = len(*h)
+	*h = append(*h, itm)
+}
+
+func (h *expiryHeap[K, V]) Pop() any {
+	old := *h
+	n := len(old)
+	itm := old[n-1]
+	old[n-1] = nil
+	itm.index = -1
+	*h = old[:n-1]
+	return itm
+}
+
+func (h *expiryHeap[K, V]) push(itm *item[K, V]) {
+	heap.Push(h, itm)
+}
+
+func (h *expiryHeap[K, V]) remove(itm *item[K, V]) {
+	if itm.index < 0 {
+		return
+	}
+	heap.Remove(h, itm.index)
+}
+
+func (h expiryHeap[K, V]) peek() *item[K, V] {
+	if len(h) == 0 {
+		return nil
+	}
+	return h[0]
+}
@@ -0,0 +1,117 @@
+package local_cache
+
+import (
+	"context"
+	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/clock/fake_clock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTypedBuildInMapCache_Sweep(t *testing.T) {
+	start := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	testCase := []struct {
+		name        string
+		sweepLimit  int
+		before      func(c *TypedBuildInMapCache[string, string])
+		after       time.Duration
+		wantCnt     int
+		wantKeys    []string
+		wantEvicted map[string]cache.EvictReason
+	}{
+		{
+			name:       "remove exactly expired",
+			sweepLimit: 1000,
+			before: func(c *TypedBuildInMapCache[string, string]) {
+				_ = c.Set(context.Background(), "k1", "v1", time.Second)
+				_ = c.Set(context.Background(), "k2", "v2", time.Second*3)
+				_ = c.Set(context.Background(), "k3", "v3", 0)
+				_ = c.Set(context.Background(), "k4", "v4", time.Second*2)
+			},
+			after:    time.Second*2 + time.Millisecond,
+			wantCnt:  2,
+			wantKeys: []string{"k2", "k3"},
+			wantEvicted: map[string]cache.EvictReason{
+				"k1": cache.EvictReasonExpired,
+				"k4": cache.EvictReasonExpired,
+			},
+		},
+		{
+			name:       "overwrite with longer expiration",
+			sweepLimit: 1000,
+			before: func(c *TypedBuildInMapCache[string, string]) {
+				_ = c.Set(context.Background(), "k1", "v1", time.Second)
+				_ = c.Set(context.Background(), "k1", "v1", time.Minute)
+				_ = c.Set(context.Background(), "k2", "v2", time.Second)
+				_ = c.Set(context.Background(), "k2", "v2", 0)
+			},
+			after:       time.Second * 2,
+			wantCnt:     0,
+			wantKeys:    []string{"k1", "k2"},
+			wantEvicted: map[string]cache.EvictReason{},
+		},
+		{
+			name:       "bounded by sweep limit",
+			sweepLimit: 2,
+			before: func(c *TypedBuildInMapCache[string, string]) {
+				_ = c.Set(context.Background(), "k1", "v1", time.Second)
+				_ = c.Set(context.Background(), "k2", "v2", time.Second*2)
+				_ = c.Set(context.Background(), "k3", "v3", time.Second*3)
+			},
+			after:    time.Second * 4,
+			wantCnt:  2,
+			wantKeys: []string{"k3"},
+			wantEvicted: map[string]cache.EvictReason{
+				"k1": cache.EvictReasonExpired,
+				"k2": cache.EvictReasonExpired,
+			},
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			clk := fake_clock.NewFakeClock(start)
+			evicted := map[string]cache.EvictReason{}
+			c := NewTypedBuildInMapCache[string, string](10,
+				TypedBuildInMapCacheWithSweepLimit[string, string](tc.sweepLimit),
+				TypedBuildInMapCacheWithClock[string, string](clk))
+			c.OnEvictedWithReason(func(key string, val string, reason cache.EvictReason) {
+				evicted[key] = reason
+			})
+			tc.before(c)
+			clk.Advance(tc.after)
+
+			c.Mutex.Lock()
+			cnt := c.sweep()
+			c.Mutex.Unlock()
+			assert.Equal(t, tc.wantCnt, cnt)
+			assert.Equal(t, tc.wantEvicted, evicted)
+			keys := make([]string, 0, len(c.Data))
+			for key := range c.Data {
+				keys = append(keys, key)
+			}
+			assert.ElementsMatch(t, tc.wantKeys, keys)
+		})
+	}
+}
+
+func TestTypedBuildInMapCache_EvictReason(t *testing.T) {
+	clk := fake_clock.NewFakeClock(time.Now())
+	evicted := map[string]cache.EvictReason{}
+	c := NewTypedBuildInMapCache[string, string](10, TypedBuildInMapCacheWithClock[string, string](clk))
+	c.OnEvictedWithReason(func(key string, val string, reason cache.EvictReason) {
+		evicted[key] = reason
+	})
+	_ = c.Set(context.Background(), "k1", "v1", time.Second)
+	_ = c.Set(context.Background(), "k2", "v2", time.Minute)
+	clk.Advance(time.Second * 2)
+
+	_, err := c.Get(context.Background(), "k1")
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+	assert.NoError(t, c.Delete(context.Background(), "k2"))
+	assert.Equal(t, map[string]cache.EvictReason{
+		"k1": cache.EvictReasonExpired,
+		"k2": cache.EvictReasonDeleted,
+	}, evicted)
+	assert.Equal(t, 0, c.expiry.Len())
+}
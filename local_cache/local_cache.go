@@ -2,67 +2,41 @@ package local_cache
 
 import (
 	"context"
-	"fmt"
 	"github.com/ac-zht/cache"
-	"sync"
+	"github.com/ac-zht/cache/clock"
 	"time"
 )
 
 type BuildInMapCacheOption func(cache *BuildInMapCache)
 
+// BuildInMapCache 值类型为 any 的本地缓存
 type BuildInMapCache struct {
-	Data        map[string]*item
-	outInterval time.Duration
-	Mutex       *sync.RWMutex
-	close       chan struct{}
-	OnEvicted   func(key string, val any)
-}
-
-type item struct {
-	val      any
-	deadline time.Time
-}
+	*TypedBuildInMapCache[string, any]
+	OnEvicted func(key string, val any)
 
-func (i *item) deadlineBefore(time time.Time) bool {
-	return i.deadline.IsZero() && i.deadline.Before(time)
+	onEvictedReason func(key string, val any, reason cache.EvictReason)
+	//hooks 包装缓存注册的内部回调，不会被 OnEvicted、OnEvictedWithReason 覆盖
+	hooks []func(key string, val any, reason cache.EvictReason)
 }
 
 func NewBuildInMapCache(cap int, opts ...BuildInMapCacheOption) *BuildInMapCache {
-	cache := &BuildInMapCache{
-		Data:        make(map[string]*item, cap),
-		outInterval: time.Hour,
-		Mutex:       &sync.RWMutex{},
-		close:       make(chan struct{}),
-		OnEvicted:   func(key string, val any) {},
+	res := &BuildInMapCache{
+		TypedBuildInMapCache: newTypedBuildInMapCache[string, any](cap),
+		OnEvicted:            func(key string, val any) {},
+		onEvictedReason:      func(key string, val any, reason cache.EvictReason) {},
+	}
+	res.onEvicted = func(key string, val any, reason cache.EvictReason) {
+		for _, hook := range res.hooks {
+			hook(key, val, reason)
+		}
+		res.OnEvicted(key, val)
+		res.onEvictedReason(key, val, reason)
 	}
 	for _, opt := range opts {
-		opt(cache)
+		opt(res)
 	}
-
-	go func() {
-		ticker := time.NewTicker(cache.outInterval)
-		for {
-			select {
-			case <-ticker.C:
-				cache.Mutex.Lock()
-				cnt := 0
-				for key, res := range cache.Data {
-					if cnt > 1000 {
-						break
-					}
-					if res.deadlineBefore(time.Now()) {
-						cache.delete(key)
-					}
-					cnt++
-				}
-				cache.Mutex.Unlock()
-
-			case <-cache.close:
-				return
-			}
-		}
-	}()
-	return cache
+	res.start()
+	return res
 }
 
 func BuildInMapCacheWithOutInterval(interval time.Duration) BuildInMapCacheOption {
@@ -71,69 +45,52 @@ func BuildInMapCacheWithOutInterval(interval time.Duration) BuildInMapCacheOptio
 	}
 }
 
-func BuildInMapCacheWithEvictedCallback(fn func(key string, val any)) BuildInMapCacheOption {
+// BuildInMapCacheWithClock 替换过期判断和定时清理使用的时钟
+func BuildInMapCacheWithClock(clock clock.Clock) BuildInMapCacheOption {
 	return func(cache *BuildInMapCache) {
-		cache.OnEvicted = fn
+		cache.clock = clock
 	}
 }
 
-func (c *BuildInMapCache) Set(ctx context.Context, key string, val any, expiration time.Duration) error {
-	var dl time.Time
-	if expiration > 0 {
-		dl = time.Now().Add(expiration)
-	}
-	c.Data[key] = &item{
-		val:      val,
-		deadline: dl,
+// BuildInMapCacheWithSweepLimit 每次定时清理最多移除的过期项数量
+func BuildInMapCacheWithSweepLimit(limit int) BuildInMapCacheOption {
+	return func(cache *BuildInMapCache) {
+		cache.sweepLimit = limit
 	}
-	return nil
 }
 
-func (c *BuildInMapCache) Get(ctx context.Context, key string) (any, error) {
-	c.Mutex.RLock()
-	res, ok := c.Data[key]
-	c.Mutex.RUnlock()
-	if !ok {
-		return nil, fmt.Errorf("%w, key: %s", cache.ErrKeyNotFound, key)
-	}
-	if res.deadlineBefore(time.Now()) {
-		c.Mutex.Lock()
-		defer c.Mutex.Unlock()
-		res, ok = c.Data[key]
-		if !ok {
-			return nil, fmt.Errorf("%w, key: %s", cache.ErrKeyNotFound, key)
-		}
-		if res.deadlineBefore(time.Now()) {
-			c.delete(key)
-			return nil, fmt.Errorf("%w, key: %s", cache.ErrKeyNotFound, key)
-		}
+func BuildInMapCacheWithEvictedCallback(fn func(key string, val any)) BuildInMapCacheOption {
+	return func(cache *BuildInMapCache) {
+		cache.OnEvicted = fn
 	}
-	return res.val, nil
 }
 
-func (c *BuildInMapCache) LoadAndDelete(ctx context.Context, key string) (any, error) {
+func (c *BuildInMapCache) Set(ctx context.Context, key string, val any, expiration time.Duration) error {
 	c.Mutex.Lock()
 	defer c.Mutex.Unlock()
-	res, ok := c.Data[key]
-	if !ok {
-		return nil, fmt.Errorf("%w, key: %s", cache.ErrKeyNotFound, key)
+	return c.SetLocked(ctx, key, val, expiration)
+}
+
+// SetLocked 供持有 Mutex 的装饰器调用，调用方需持有锁
+func (c *BuildInMapCache) SetLocked(ctx context.Context, key string, val any, expiration time.Duration) error {
+	if c.closed {
+		return cache.ErrClosed
 	}
-	c.delete(key)
-	return res.val, nil
+	c.set(key, val, expiration)
+	return nil
 }
 
-func (c *BuildInMapCache) Delete(ctx context.Context, key string) error {
+// AddEvictedHook 追加一个淘汰回调，先于 OnEvicted 字段和 OnEvictedWithReason 执行且不会被它们覆盖，
+// 供包装 BuildInMapCache 的缓存维护自身状态，回调在锁内执行
+func (c *BuildInMapCache) AddEvictedHook(fn func(key string, val any, reason cache.EvictReason)) {
 	c.Mutex.Lock()
 	defer c.Mutex.Unlock()
-	c.delete(key)
-	return nil
+	c.hooks = append(c.hooks, fn)
 }
 
-func (c *BuildInMapCache) delete(key string) {
-	val, ok := c.Data[key]
-	if !ok {
-		return
-	}
-	delete(c.Data, key)
-	c.OnEvicted(key, val)
+// OnEvictedWithReason 注册带移除原因的淘汰回调，与 OnEvicted 字段同时生效
+func (c *BuildInMapCache) OnEvictedWithReason(fn func(key string, val any, reason cache.EvictReason)) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+	c.onEvictedReason = fn
 }
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/clock/fake_clock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -39,8 +41,10 @@ func TestBuildInMapCache_Get(t *testing.T) {
 		{
 			name: "expired",
 			cache: func() *BuildInMapCache {
-				res := NewBuildInMapCache(10)
-				_ = res.Set(context.Background(), "key", "value", -time.Minute)
+				clk := fake_clock.NewFakeClock(time.Now())
+				res := NewBuildInMapCache(10, BuildInMapCacheWithClock(clk))
+				_ = res.Set(context.Background(), "key", "value", time.Minute)
+				clk.Advance(time.Minute * 2)
 				return res
 			},
 			key:       "key",
@@ -61,16 +65,53 @@ func TestBuildInMapCache_Get(t *testing.T) {
 }
 
 func TestBuildInMapCache_IntervalEliminate(t *testing.T) {
-	cnt := 0
-	cache := NewBuildInMapCache(10, BuildInMapCacheWithOutInterval(time.Second), BuildInMapCacheWithEvictedCallback(func(key string, val any) {
-		cnt++
-	}))
+	clk := fake_clock.NewFakeClock(time.Now())
+	cnt := int32(0)
+	cache := NewBuildInMapCache(10, BuildInMapCacheWithOutInterval(time.Second), BuildInMapCacheWithClock(clk),
+		BuildInMapCacheWithEvictedCallback(func(key string, val any) {
+			atomic.AddInt32(&cnt, 1)
+		}))
 	err := cache.Set(context.Background(), "k1", "v1", time.Second)
 	err = cache.Set(context.Background(), "k2", "v2", time.Second*2)
 	err = cache.Set(context.Background(), "k3", "v3", time.Second*3)
 	assert.NoError(t, err)
-	time.Sleep(time.Second * 4)
+	clk.Advance(time.Second * 4)
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&cnt) == 3
+	}, time.Second, time.Millisecond)
+	cache.Mutex.RLock()
 	_, ok := cache.Data["k3"]
+	cache.Mutex.RUnlock()
 	require.False(t, ok)
-	require.Equal(t, 3, cnt)
+}
+
+func TestBuildInMapCache_Close(t *testing.T) {
+	c := NewBuildInMapCache(10, BuildInMapCacheWithOutInterval(time.Millisecond))
+	_ = c.Set(context.Background(), "key", "value", time.Minute)
+	require.NoError(t, c.Close(context.Background()))
+	assert.Equal(t, cache.ErrClosed, c.Close(context.Background()))
+
+	_, err := c.Get(context.Background(), "key")
+	assert.Equal(t, cache.ErrClosed, err)
+	assert.Equal(t, cache.ErrClosed, c.Set(context.Background(), "key", "value", time.Minute))
+	assert.Equal(t, cache.ErrClosed, c.Delete(context.Background(), "key"))
+	_, err = c.LoadAndDelete(context.Background(), "key")
+	assert.Equal(t, cache.ErrClosed, err)
+	_, err = NewBytesCache(10).Get(context.Background(), "key")
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+}
+
+func TestBuildInMapCache_ConcurrentSetClose(t *testing.T) {
+	//Set 自行加锁，与 Close 并发时没有数据竞争
+	c := NewBuildInMapCache(10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_ = c.Set(context.Background(), "key", "value", time.Minute)
+		}
+	}()
+	require.NoError(t, c.Close(context.Background()))
+	<-done
+	assert.Equal(t, cache.ErrClosed, c.Set(context.Background(), "key", "value", time.Minute))
 }
@@ -0,0 +1,237 @@
+package local_cache
+
+import (
+	"context"
+	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/clock"
+	"github.com/ac-zht/cache/metrics"
+	"hash/fnv"
+	"time"
+)
+
+var (
+	_ cache.Cache      = &ShardedCache{}
+	_ cache.BatchCache = &ShardedCache{}
+)
+
+type ShardedCacheOption func(cache *ShardedCache)
+
+// ShardedCache 按 key 的哈希值分片的 []byte 缓存，每个分片有独立的锁、过期清理和内存统计，
+// 不同分片上的操作互不阻塞
+type ShardedCache struct {
+	shards      []*cacheShard
+	mask        uint32
+	outInterval time.Duration
+	clock       clock.Clock
+}
+
+type cacheShard struct {
+	*TypedBuildInMapCache[string, []byte]
+	fn       func(key string, val []byte)
+	reasonFn func(key string, val []byte, reason cache.EvictReason)
+	hooks    []func(key string, val []byte, reason cache.EvictReason)
+}
+
+// NewShardedCache shardCnt 向上取整为 2 的幂，cap 为每个分片的初始容量
+func NewShardedCache(shardCnt int, cap int, opts ...ShardedCacheOption) *ShardedCache {
+	n := 1
+	for n < shardCnt {
+		n <<= 1
+	}
+	res := &ShardedCache{
+		shards:      make([]*cacheShard, n),
+		mask:        uint32(n - 1),
+		outInterval: time.Hour,
+		clock:       clock.New(),
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+	for i := range res.shards {
+		res.shards[i] = res.newShard(cap)
+	}
+	return res
+}
+
+func ShardedCacheWithOutInterval(interval time.Duration) ShardedCacheOption {
+	return func(cache *ShardedCache) {
+		cache.outInterval = interval
+	}
+}
+
+// ShardedCacheWithClock 替换所有分片使用的时钟
+func ShardedCacheWithClock(clock clock.Clock) ShardedCacheOption {
+	return func(cache *ShardedCache) {
+		cache.clock = clock
+	}
+}
+
+func (c *ShardedCache) newShard(cap int) *cacheShard {
+	s := &cacheShard{
+		TypedBuildInMapCache: newTypedBuildInMapCache[string, []byte](cap),
+		fn:                   func(key string, val []byte) {},
+		reasonFn:             func(key string, val []byte, reason cache.EvictReason) {},
+	}
+	s.outInterval = c.outInterval
+	s.clock = c.clock
+	s.size = bytesSize
+	//回调在分片锁内执行
+	s.onEvicted = func(key string, val []byte, reason cache.EvictReason) {
+		for _, hook := range s.hooks {
+			hook(key, val, reason)
+		}
+		s.fn(key, val)
+		s.reasonFn(key, val, reason)
+	}
+	s.start()
+	return s
+}
+
+func (c *ShardedCache) shard(key string) *cacheShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return c.shards[h.Sum32()&c.mask]
+}
+
+func (c *ShardedCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return c.shard(key).Get(ctx, key)
+}
+
+func (c *ShardedCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
+	s := c.shard(key)
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+	if s.closed {
+		return cache.ErrClosed
+	}
+	s.set(key, val, expiration)
+	return nil
+}
+
+func (c *ShardedCache) Delete(ctx context.Context, key string) error {
+	return c.shard(key).Delete(ctx, key)
+}
+
+func (c *ShardedCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	return c.shard(key).LoadAndDelete(ctx, key)
+}
+
+// GetMulti 按分片分组，每个分片只加一次锁
+func (c *ShardedCache) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	res := make(map[string][]byte, len(keys))
+	for s, ks := range c.group(keys) {
+		vals, err := s.GetMulti(ctx, ks)
+		if err != nil {
+			return res, err
+		}
+		for key, val := range vals {
+			res[key] = val
+		}
+	}
+	return res, nil
+}
+
+func (c *ShardedCache) SetMulti(ctx context.Context, vals map[string][]byte, expiration time.Duration) error {
+	keys := make([]string, 0, len(vals))
+	for key := range vals {
+		keys = append(keys, key)
+	}
+	for s, ks := range c.group(keys) {
+		if err := s.setMulti(ks, vals, expiration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ShardedCache) DeleteMulti(ctx context.Context, keys []string) error {
+	for s, ks := range c.group(keys) {
+		if err := s.DeleteMulti(ctx, ks); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ShardedCache) group(keys []string) map[*cacheShard][]string {
+	res := make(map[*cacheShard][]string)
+	for _, key := range keys {
+		s := c.shard(key)
+		res[s] = append(res[s], key)
+	}
+	return res
+}
+
+func (s *cacheShard) setMulti(keys []string, vals map[string][]byte, expiration time.Duration) error {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+	if s.closed {
+		return cache.ErrClosed
+	}
+	for _, key := range keys {
+		s.set(key, vals[key], expiration)
+	}
+	return nil
+}
+
+func (c *ShardedCache) OnEvicted(fn func(key string, val []byte)) {
+	for _, s := range c.shards {
+		s.Mutex.Lock()
+		s.fn = fn
+		s.Mutex.Unlock()
+	}
+}
+
+// OnEvictedWithReason 注册带移除原因的淘汰回调，与 OnEvicted 同时生效
+func (c *ShardedCache) OnEvictedWithReason(fn func(key string, val []byte, reason cache.EvictReason)) {
+	for _, s := range c.shards {
+		s.Mutex.Lock()
+		s.reasonFn = fn
+		s.Mutex.Unlock()
+	}
+}
+
+// AddEvictedHook 追加一个淘汰回调，不会被 OnEvicted、OnEvictedWithReason 覆盖
+func (c *ShardedCache) AddEvictedHook(fn func(key string, val []byte, reason cache.EvictReason)) {
+	for _, s := range c.shards {
+		s.Mutex.Lock()
+		s.hooks = append(s.hooks, fn)
+		s.Mutex.Unlock()
+	}
+}
+
+// Close 关闭所有分片
+func (c *ShardedCache) Close(ctx context.Context) error {
+	var res error
+	for _, s := range c.shards {
+		if err := s.Close(ctx); err != nil {
+			res = err
+		}
+	}
+	return res
+}
+
+// Used 所有分片中 value 占用的字节数
+func (c *ShardedCache) Used() int64 {
+	return c.Stats().Bytes
+}
+
+// Stats 汇总所有分片的统计
+func (c *ShardedCache) Stats() metrics.Stats {
+	var res metrics.Stats
+	for _, s := range c.shards {
+		res = res.Merge(s.Stats())
+	}
+	return res
+}
+
+// Len 所有分片中的 key 数量
+func (c *ShardedCache) Len() int {
+	res := 0
+	for _, s := range c.shards {
+		s.Mutex.RLock()
+		res += len(s.Data)
+		s.Mutex.RUnlock()
+	}
+	return res
+}
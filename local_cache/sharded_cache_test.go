@@ -0,0 +1,160 @@
+package local_cache
+
+import (
+	"context"
+	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/max_memory_cache"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestShardedCache_Set(t *testing.T) {
+	testCase := []struct {
+		name     string
+		before   func(c *ShardedCache)
+		key      string
+		val      []byte
+		wantUsed int64
+		wantLen  int
+	}{
+		{
+			name:     "not exist",
+			before:   func(c *ShardedCache) {},
+			key:      "key",
+			val:      []byte("value"),
+			wantUsed: 5,
+			wantLen:  1,
+		},
+		{
+			name: "existed",
+			before: func(c *ShardedCache) {
+				_ = c.Set(context.Background(), "k", []byte("v"), time.Minute)
+				_ = c.Set(context.Background(), "key", []byte("value"), time.Minute)
+			},
+			key:      "key",
+			val:      []byte("this value"),
+			wantUsed: 11,
+			wantLen:  2,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewShardedCache(4, 10)
+			tc.before(c)
+			err := c.Set(context.Background(), tc.key, tc.val, time.Minute)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantUsed, c.Used())
+			assert.Equal(t, tc.wantLen, c.Len())
+			val, err := c.Get(context.Background(), tc.key)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.val, val)
+		})
+	}
+}
+
+func TestShardedCache_LoadAndDelete(t *testing.T) {
+	c := NewShardedCache(4, 10)
+	evicted := map[string][]byte{}
+	c.OnEvicted(func(key string, val []byte) {
+		evicted[key] = val
+	})
+	for i := 0; i < 10; i++ {
+		_ = c.Set(context.Background(), strconv.Itoa(i), []byte("v"), time.Minute)
+	}
+	val, err := c.LoadAndDelete(context.Background(), "3")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("v"), val)
+	assert.NoError(t, c.Delete(context.Background(), "4"))
+	assert.Equal(t, map[string][]byte{"3": []byte("v"), "4": []byte("v")}, evicted)
+	assert.Equal(t, int64(8), c.Used())
+
+	_, err = c.Get(context.Background(), "3")
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+}
+
+func BenchmarkParallelGet(b *testing.B) {
+	const n = 100000
+	caches := []struct {
+		name  string
+		cache cache.Cache
+	}{
+		{name: "bytes_cache", cache: NewBytesCache(n)},
+		{name: "max_memory_cache", cache: max_memory_cache.NewMaxMemoryCache(n, NewBytesCache(n))},
+		{name: "sharded_cache", cache: NewShardedCache(64, n/64)},
+	}
+	for _, bc := range caches {
+		for i := 0; i < n; i++ {
+			_ = bc.cache.Set(context.Background(), strconv.Itoa(i), []byte("v"), time.Hour)
+		}
+		b.Run(bc.name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					_, _ = bc.cache.Get(context.Background(), strconv.Itoa(i%n))
+					i++
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkParallelSet(b *testing.B) {
+	const n = 100000
+	caches := []struct {
+		name  string
+		cache func() cache.Cache
+	}{
+		{name: "bytes_cache", cache: func() cache.Cache { return NewBytesCache(n) }},
+		{name: "max_memory_cache", cache: func() cache.Cache {
+			return max_memory_cache.NewMaxMemoryCache(n, NewBytesCache(n))
+		}},
+		{name: "sharded_cache", cache: func() cache.Cache { return NewShardedCache(64, n/64) }},
+	}
+	for _, bc := range caches {
+		b.Run(bc.name, func(b *testing.B) {
+			c := bc.cache()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					_ = c.Set(context.Background(), strconv.Itoa(i%n), []byte("v"), time.Hour)
+					i++
+				}
+			})
+		})
+	}
+}
+
+func TestShardedCache_Multi(t *testing.T) {
+	c := NewShardedCache(4, 10)
+	vals := map[string][]byte{}
+	keys := make([]string, 0, 20)
+	for i := 0; i < 20; i++ {
+		key := "key" + strconv.Itoa(i)
+		vals[key] = []byte("value")
+		keys = append(keys, key)
+	}
+	assert.NoError(t, c.SetMulti(context.Background(), vals, time.Minute))
+	assert.Equal(t, 20, c.Len())
+	assert.Equal(t, int64(100), c.Used())
+
+	res, err := c.GetMulti(context.Background(), append(keys, "missing"))
+	assert.NoError(t, err)
+	assert.Equal(t, vals, res)
+
+	//覆盖写入时按新值统计内存
+	assert.NoError(t, c.SetMulti(context.Background(), map[string][]byte{"key0": []byte("v")}, time.Minute))
+	assert.Equal(t, int64(96), c.Used())
+
+	assert.NoError(t, c.DeleteMulti(context.Background(), keys[:10]))
+	assert.Equal(t, 10, c.Len())
+	assert.Equal(t, int64(50), c.Used())
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(20), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(21), stats.Sets)
+	assert.Equal(t, uint64(10), stats.Deletes)
+	assert.Equal(t, map[cache.EvictReason]uint64{cache.EvictReasonDeleted: 10}, stats.Evictions)
+}
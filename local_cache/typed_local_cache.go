@@ -0,0 +1,335 @@
+package local_cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/clock"
+	"github.com/ac-zht/cache/metrics"
+	"sync"
+	"time"
+)
+
+type TypedBuildInMapCacheOption[K comparable, V any] func(cache *TypedBuildInMapCache[K, V])
+
+// TypedBuildInMapCache 泛型本地缓存，实现 cache.TypedCache 接口
+type TypedBuildInMapCache[K comparable, V any] struct {
+	Data        map[K]*item[K, V]
+	outInterval time.Duration
+	Mutex       *sync.RWMutex
+	close       chan struct{}
+	closed      bool
+	onEvicted   func(key K, val V, reason cache.EvictReason)
+
+	//expiry 按过期时间排序的最小堆，每次清理最多移除 sweepLimit 个过期项
+	expiry     *expiryHeap[K, V]
+	sweepLimit int
+	clock      clock.Clock
+
+	stats *metrics.Recorder
+	//size 计算 value 占用的字节数，为空时不统计
+	size func(val V) int64
+}
+
+type item[K comparable, V any] struct {
+	key      K
+	val      V
+	deadline time.Time
+	index    int
+}
+
+func (i *item[K, V]) Val() V {
+	return i.val
+}
+
+// Deadline 过期时间，零值表示永不过期
+func (i *item[K, V]) Deadline() time.Time {
+	return i.deadline
+}
+
+func (i *item[K, V]) deadlineBefore(time time.Time) bool {
+	return !i.deadline.IsZero() && i.deadline.Before(time)
+}
+
+func NewTypedBuildInMapCache[K comparable, V any](cap int, opts ...TypedBuildInMapCacheOption[K, V]) *TypedBuildInMapCache[K, V] {
+	cache := newTypedBuildInMapCache[K, V](cap)
+	for _, opt := range opts {
+		opt(cache)
+	}
+	cache.start()
+	return cache
+}
+
+func newTypedBuildInMapCache[K comparable, V any](cap int) *TypedBuildInMapCache[K, V] {
+	return &TypedBuildInMapCache[K, V]{
+		Data:        make(map[K]*item[K, V], cap),
+		outInterval: time.Hour,
+		Mutex:       &sync.RWMutex{},
+		close:       make(chan struct{}),
+		onEvicted:   func(key K, val V, reason cache.EvictReason) {},
+		expiry:      &expiryHeap[K, V]{},
+		sweepLimit:  1000,
+		clock:       clock.New(),
+		stats:       metrics.NewRecorder(),
+	}
+}
+
+func (c *TypedBuildInMapCache[K, V]) start() {
+	ticker := c.clock.NewTicker(c.outInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C():
+				c.Mutex.Lock()
+				c.sweep()
+				c.Mutex.Unlock()
+
+			case <-c.close:
+				return
+			}
+		}
+	}()
+}
+
+// sweep 从堆顶开始移除已过期的缓存项，调用方需持有锁
+func (c *TypedBuildInMapCache[K, V]) sweep() int {
+	now := c.clock.Now()
+	cnt := 0
+	for cnt < c.sweepLimit {
+		itm := c.expiry.peek()
+		if itm == nil || !itm.deadlineBefore(now) {
+			break
+		}
+		c.Evict(itm.key, cache.EvictReasonExpired)
+		cnt++
+	}
+	return cnt
+}
+
+func TypedBuildInMapCacheWithOutInterval[K comparable, V any](interval time.Duration) TypedBuildInMapCacheOption[K, V] {
+	return func(cache *TypedBuildInMapCache[K, V]) {
+		cache.outInterval = interval
+	}
+}
+
+// TypedBuildInMapCacheWithClock 替换过期判断和定时清理使用的时钟
+func TypedBuildInMapCacheWithClock[K comparable, V any](clock clock.Clock) TypedBuildInMapCacheOption[K, V] {
+	return func(cache *TypedBuildInMapCache[K, V]) {
+		cache.clock = clock
+	}
+}
+
+// TypedBuildInMapCacheWithSweepLimit 每次定时清理最多移除的过期项数量
+func TypedBuildInMapCacheWithSweepLimit[K comparable, V any](limit int) TypedBuildInMapCacheOption[K, V] {
+	return func(cache *TypedBuildInMapCache[K, V]) {
+		cache.sweepLimit = limit
+	}
+}
+
+// TypedBuildInMapCacheWithSizeFunc 统计 value 占用的字节数，结果体现在 Stats().Bytes
+func TypedBuildInMapCacheWithSizeFunc[K comparable, V any](fn func(val V) int64) TypedBuildInMapCacheOption[K, V] {
+	return func(cache *TypedBuildInMapCache[K, V]) {
+		cache.size = fn
+	}
+}
+
+func TypedBuildInMapCacheWithEvictedCallback[K comparable, V any](fn func(key K, val V)) TypedBuildInMapCacheOption[K, V] {
+	return func(c *TypedBuildInMapCache[K, V]) {
+		c.onEvicted = func(key K, val V, reason cache.EvictReason) {
+			fn(key, val)
+		}
+	}
+}
+
+func (c *TypedBuildInMapCache[K, V]) Set(ctx context.Context, key K, val V, expiration time.Duration) error {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+	if c.closed {
+		return cache.ErrClosed
+	}
+	c.set(key, val, expiration)
+	return nil
+}
+
+// set expiration 不大于 0 表示永不过期
+func (c *TypedBuildInMapCache[K, V]) set(key K, val V, expiration time.Duration) {
+	var dl time.Time
+	if expiration > 0 {
+		dl = c.clock.Now().Add(expiration)
+	}
+	if old, ok := c.Data[key]; ok {
+		c.expiry.remove(old)
+		c.addBytes(old.val, -1)
+	}
+	itm := &item[K, V]{
+		key:      key,
+		val:      val,
+		deadline: dl,
+		index:    -1,
+	}
+	c.Data[key] = itm
+	if !dl.IsZero() {
+		c.expiry.push(itm)
+	}
+	c.stats.Set()
+	c.addBytes(val, 1)
+}
+
+func (c *TypedBuildInMapCache[K, V]) addBytes(val V, sign int64) {
+	if c.size != nil {
+		c.stats.AddBytes(sign * c.size(val))
+	}
+}
+
+func (c *TypedBuildInMapCache[K, V]) Get(ctx context.Context, key K) (V, error) {
+	var zero V
+	c.Mutex.RLock()
+	res, ok := c.Data[key]
+	closed := c.closed
+	c.Mutex.RUnlock()
+	if closed {
+		return zero, cache.ErrClosed
+	}
+	if !ok {
+		c.stats.Miss()
+		return zero, fmt.Errorf("%w, key: %v", cache.ErrKeyNotFound, key)
+	}
+	if res.deadlineBefore(c.clock.Now()) {
+		c.Mutex.Lock()
+		defer c.Mutex.Unlock()
+		res, ok = c.Data[key]
+		if !ok {
+			c.stats.Miss()
+			return zero, fmt.Errorf("%w, key: %v", cache.ErrKeyNotFound, key)
+		}
+		if res.deadlineBefore(c.clock.Now()) {
+			c.Evict(key, cache.EvictReasonExpired)
+			c.stats.Miss()
+			return zero, fmt.Errorf("%w, key: %v", cache.ErrKeyNotFound, key)
+		}
+	}
+	c.stats.Hit()
+	return res.val, nil
+}
+
+func (c *TypedBuildInMapCache[K, V]) LoadAndDelete(ctx context.Context, key K) (V, error) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+	if c.closed {
+		var zero V
+		return zero, cache.ErrClosed
+	}
+	c.stats.Delete()
+	val, ok := c.Evict(key, cache.EvictReasonDeleted)
+	if !ok {
+		return val, fmt.Errorf("%w, key: %v", cache.ErrKeyNotFound, key)
+	}
+	return val, nil
+}
+
+func (c *TypedBuildInMapCache[K, V]) Delete(ctx context.Context, key K) error {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+	if c.closed {
+		return cache.ErrClosed
+	}
+	c.stats.Delete()
+	c.Evict(key, cache.EvictReasonDeleted)
+	return nil
+}
+
+// GetMulti 只加一次读锁，已过期的 key 视为未命中，留给定时清理移除
+func (c *TypedBuildInMapCache[K, V]) GetMulti(ctx context.Context, keys []K) (map[K]V, error) {
+	c.Mutex.RLock()
+	defer c.Mutex.RUnlock()
+	if c.closed {
+		return nil, cache.ErrClosed
+	}
+	now := c.clock.Now()
+	res := make(map[K]V, len(keys))
+	for _, key := range keys {
+		if itm, ok := c.Data[key]; ok && !itm.deadlineBefore(now) {
+			res[key] = itm.val
+			c.stats.Hit()
+		} else {
+			c.stats.Miss()
+		}
+	}
+	return res, nil
+}
+
+func (c *TypedBuildInMapCache[K, V]) SetMulti(ctx context.Context, vals map[K]V, expiration time.Duration) error {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+	if c.closed {
+		return cache.ErrClosed
+	}
+	for key, val := range vals {
+		c.set(key, val, expiration)
+	}
+	return nil
+}
+
+func (c *TypedBuildInMapCache[K, V]) DeleteMulti(ctx context.Context, keys []K) error {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+	if c.closed {
+		return cache.ErrClosed
+	}
+	for _, key := range keys {
+		c.stats.Delete()
+		c.Evict(key, cache.EvictReasonDeleted)
+	}
+	return nil
+}
+
+// Close 停止后台清理 goroutine，之后的读写操作返回 cache.ErrClosed
+func (c *TypedBuildInMapCache[K, V]) Close(ctx context.Context) error {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+	if c.closed {
+		return cache.ErrClosed
+	}
+	c.closed = true
+	close(c.close)
+	return nil
+}
+
+func (c *TypedBuildInMapCache[K, V]) OnEvicted(fn func(key K, val V)) {
+	c.OnEvictedWithReason(func(key K, val V, reason cache.EvictReason) {
+		fn(key, val)
+	})
+}
+
+// OnEvictedWithReason 注册带移除原因的淘汰回调
+func (c *TypedBuildInMapCache[K, V]) OnEvictedWithReason(fn func(key K, val V, reason cache.EvictReason)) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+	c.onEvicted = fn
+}
+
+// Stats 返回统计快照，未设置 value 大小计算方式时 Bytes 为 0
+func (c *TypedBuildInMapCache[K, V]) Stats() metrics.Stats {
+	return c.stats.Snapshot()
+}
+
+// Closed 是否已关闭，供持有 Mutex 的装饰器调用，调用方需持有锁
+func (c *TypedBuildInMapCache[K, V]) Closed() bool {
+	return c.closed
+}
+
+// Evict 移除 key 并触发淘汰回调，供持有 Mutex 的装饰器调用，调用方需持有锁
+func (c *TypedBuildInMapCache[K, V]) Evict(key K, reason cache.EvictReason) (V, bool) {
+	itm, ok := c.Data[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	delete(c.Data, key)
+	c.expiry.remove(itm)
+	c.stats.Evict(reason)
+	c.addBytes(itm.val, -1)
+	c.onEvicted(key, itm.val, reason)
+	return itm.val, true
+}
@@ -0,0 +1,103 @@
+package local_cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/clock/fake_clock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type user struct {
+	Id   int64
+	Name string
+}
+
+func TestTypedBuildInMapCache_Get(t *testing.T) {
+	testCase := []struct {
+		name      string
+		cache     func() *TypedBuildInMapCache[int64, user]
+		key       int64
+		wantVal   user
+		wantError error
+	}{
+		{
+			name: "get",
+			cache: func() *TypedBuildInMapCache[int64, user] {
+				res := NewTypedBuildInMapCache[int64, user](10)
+				_ = res.Set(context.Background(), 1, user{Id: 1, Name: "Tom"}, time.Minute)
+				return res
+			},
+			key:     1,
+			wantVal: user{Id: 1, Name: "Tom"},
+		},
+		{
+			name: "not exist",
+			cache: func() *TypedBuildInMapCache[int64, user] {
+				return NewTypedBuildInMapCache[int64, user](10)
+			},
+			key:       1,
+			wantError: fmt.Errorf("%w, key: %d", cache.ErrKeyNotFound, 1),
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cache()
+			val, err := c.Get(context.Background(), tc.key)
+			assert.Equal(t, tc.wantError, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantVal, val)
+		})
+	}
+}
+
+func TestTypedBuildInMapCache_LoadAndDelete(t *testing.T) {
+	evicted := map[int64]user{}
+	var c cache.TypedCache[int64, user] = NewTypedBuildInMapCache[int64, user](10,
+		TypedBuildInMapCacheWithEvictedCallback(func(key int64, val user) {
+			evicted[key] = val
+		}))
+	err := c.Set(context.Background(), 1, user{Id: 1, Name: "Tom"}, time.Minute)
+	assert.NoError(t, err)
+	val, err := c.LoadAndDelete(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, user{Id: 1, Name: "Tom"}, val)
+	assert.Equal(t, map[int64]user{1: {Id: 1, Name: "Tom"}}, evicted)
+
+	_, err = c.LoadAndDelete(context.Background(), 1)
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+}
+
+func TestTypedBuildInMapCache_Multi(t *testing.T) {
+	clk := fake_clock.NewFakeClock(time.Now())
+	c := NewTypedBuildInMapCache[int64, user](10, TypedBuildInMapCacheWithClock[int64, user](clk))
+	evicted := map[int64]cache.EvictReason{}
+	c.OnEvictedWithReason(func(key int64, val user, reason cache.EvictReason) {
+		evicted[key] = reason
+	})
+	err := c.SetMulti(context.Background(), map[int64]user{
+		1: {Id: 1, Name: "Tom"},
+		2: {Id: 2, Name: "Jerry"},
+	}, time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Set(context.Background(), 3, user{Id: 3}, time.Second))
+	clk.Advance(time.Second * 2)
+
+	vals, err := c.GetMulti(context.Background(), []int64{1, 2, 3, 4})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]user{1: {Id: 1, Name: "Tom"}, 2: {Id: 2, Name: "Jerry"}}, vals)
+
+	assert.NoError(t, c.DeleteMulti(context.Background(), []int64{1, 4}))
+	assert.Equal(t, map[int64]cache.EvictReason{1: cache.EvictReasonDeleted}, evicted)
+	assert.Len(t, c.Data, 2)
+
+	assert.NoError(t, c.Close(context.Background()))
+	_, err = c.GetMulti(context.Background(), []int64{2})
+	assert.Equal(t, cache.ErrClosed, err)
+	assert.Equal(t, cache.ErrClosed, c.SetMulti(context.Background(), map[int64]user{}, time.Minute))
+	assert.Equal(t, cache.ErrClosed, c.DeleteMulti(context.Background(), []int64{2}))
+}
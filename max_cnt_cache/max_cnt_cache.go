@@ -4,16 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/eviction"
 	"github.com/ac-zht/cache/local_cache"
 	"time"
 )
 
-var errKeyExceedMaxCnt = errors.New("cache: key exceed max cnt")
+// ErrKeyExceedMaxCnt 拒绝模式下数量已满时返回
+var ErrKeyExceedMaxCnt = errors.New("cache: key exceed max cnt")
 
 type MaxCntCache struct {
 	*local_cache.BuildInMapCache
 	max int
 	cnt int
+	//policy 为空时数量已满拒绝写入，否则按策略淘汰一个 key，默认拒绝写入
+	policy eviction.Policy
 }
 
 type MaxCntCacheOption func(cache *MaxCntCache)
@@ -26,26 +31,130 @@ func NewMaxCntCache(max int, cache *local_cache.BuildInMapCache, opts ...MaxCntC
 	for _, opt := range opts {
 		opt(res)
 	}
+	res.AddEvictedHook(res.evicted)
 	return res
 }
 
 func (m *MaxCntCache) Set(ctx context.Context, key string, val any, expiration time.Duration) error {
 	m.Mutex.Lock()
 	defer m.Mutex.Unlock()
+	if m.Closed() {
+		return cache.ErrClosed
+	}
 	_, ok := m.Data[key]
 	if !ok {
 		if m.cnt+1 > m.max {
-			return fmt.Errorf("%w, key : %s", errKeyExceedMaxCnt, key)
+			if err := m.evict(key); err != nil {
+				return err
+			}
 		}
 		m.cnt++
 	}
-	return m.BuildInMapCache.Set(ctx, key, val, expiration)
+	if m.policy != nil {
+		m.policy.Add(key)
+	}
+	return m.SetLocked(ctx, key, val, expiration)
+}
+
+func (m *MaxCntCache) Get(ctx context.Context, key string) (any, error) {
+	val, err := m.BuildInMapCache.Get(ctx, key)
+	if err == nil && m.policy != nil {
+		m.Mutex.Lock()
+		m.policy.Access(key)
+		m.Mutex.Unlock()
+	}
+	return val, err
 }
 
+// evict 淘汰一个 key 为新 key 腾出位置，调用方需持有锁
+func (m *MaxCntCache) evict(key string) error {
+	if m.policy == nil {
+		return fmt.Errorf("%w, key : %s", ErrKeyExceedMaxCnt, key)
+	}
+	for {
+		victim, ok := m.policy.Victim()
+		if !ok {
+			return fmt.Errorf("%w, key : %s", ErrKeyExceedMaxCnt, key)
+		}
+		if _, ok = m.Data[victim]; ok {
+			m.Evict(victim, cache.EvictReasonCapacity)
+			return nil
+		}
+		//policy 中残留已不在缓存中的 key，移除后继续选择
+		m.policy.Remove(victim)
+	}
+}
+
+// evicted 缓存项被移除时减少计数并同步 policy，在锁内执行
+func (m *MaxCntCache) evicted(key string, val any, reason cache.EvictReason) {
+	m.cnt--
+	if m.policy != nil {
+		m.policy.Remove(key)
+	}
+}
+
+// MaxCntCacheWithEvictedCallback 计数已在缓存项被移除时自动维护，保留该选项只为兼容
 func MaxCntCacheWithEvictedCallback() MaxCntCacheOption {
+	return func(cache *MaxCntCache) {}
+}
+
+// MaxCntCacheWithPolicy 数量已满时按 policy 淘汰
+func MaxCntCacheWithPolicy(policy eviction.Policy) MaxCntCacheOption {
+	return func(cache *MaxCntCache) {
+		cache.policy = policy
+	}
+}
+
+// MaxCntCacheWithReject 数量已满时拒绝写入，返回 ErrKeyExceedMaxCnt
+func MaxCntCacheWithReject() MaxCntCacheOption {
+	return func(cache *MaxCntCache) {
+		cache.policy = nil
+	}
+}
+
+// MaxCntCacheWithEvictOldest 数量已满时淘汰最早写入的 key
+func MaxCntCacheWithEvictOldest() MaxCntCacheOption {
+	return MaxCntCacheWithPolicy(eviction.NewFIFO())
+}
+
+// MaxCntCacheWithEvictLRU 数量已满时淘汰最久未访问的 key
+func MaxCntCacheWithEvictLRU() MaxCntCacheOption {
+	return MaxCntCacheWithPolicy(eviction.NewLRU())
+}
+
+// MaxCntCacheWithEvictSoonestExpire 数量已满时淘汰最快过期的 key，没有过期时间的 key 最后淘汰
+func MaxCntCacheWithEvictSoonestExpire() MaxCntCacheOption {
 	return func(cache *MaxCntCache) {
-		cache.OnEvicted = func(key string, val any) {
-			cache.cnt--
+		cache.policy = &expirePolicy{cache: cache}
+	}
+}
+
+// expirePolicy 遍历缓存找出过期时间最早的 key，调用方需持有锁
+type expirePolicy struct {
+	cache *MaxCntCache
+}
+
+func (p *expirePolicy) Add(key string) {}
+
+func (p *expirePolicy) Access(key string) {}
+
+func (p *expirePolicy) Remove(key string) {}
+
+func (p *expirePolicy) Victim() (string, bool) {
+	var (
+		victim   string
+		deadline time.Time
+		found    bool
+	)
+	for key, itm := range p.cache.Data {
+		dl := itm.Deadline()
+		if !found || (!dl.IsZero() && (deadline.IsZero() || dl.Before(deadline))) {
+			victim, deadline, found = key, dl, true
 		}
 	}
+	return victim, found
+}
+
+func (p *expirePolicy) Len() int {
+	return len(p.cache.Data)
 }
@@ -1,10 +1,12 @@
 package max_cnt_cache
 
 import (
-	"cache"
-	"cache/local_cache"
 	"context"
+	"errors"
 	"fmt"
+	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/eviction"
+	"github.com/ac-zht/cache/local_cache"
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"time"
@@ -50,7 +52,7 @@ func TestMaxCntCache_Set(t *testing.T) {
 			},
 			key:       "k3",
 			val:       "v3",
-			wantError: fmt.Errorf("%w, key : %s", errKeyExceedMaxCnt, "k3"),
+			wantError: fmt.Errorf("%w, key : %s", ErrKeyExceedMaxCnt, "k3"),
 		},
 	}
 	for _, tc := range testCase {
@@ -110,3 +112,96 @@ func TestMaxCntCache_LoadAndDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestMaxCntCache_Evict(t *testing.T) {
+	testCase := []struct {
+		name        string
+		opt         MaxCntCacheOption
+		wantEvicted []string
+	}{
+		{
+			name:        "evict lru",
+			opt:         MaxCntCacheWithEvictLRU(),
+			wantEvicted: []string{"k2"},
+		},
+		{
+			name:        "evict oldest",
+			opt:         MaxCntCacheWithEvictOldest(),
+			wantEvicted: []string{"k1"},
+		},
+		{
+			name:        "evict soonest expire",
+			opt:         MaxCntCacheWithEvictSoonestExpire(),
+			wantEvicted: []string{"k2"},
+		},
+		{
+			name:        "evict by policy",
+			opt:         MaxCntCacheWithPolicy(eviction.NewLFU()),
+			wantEvicted: []string{"k2"},
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			evicted := make([]string, 0, 1)
+			res := NewMaxCntCache(2, local_cache.NewBuildInMapCache(2), MaxCntCacheWithEvictedCallback(), tc.opt)
+			//覆盖 OnEvicted 不影响计数和 policy 的维护
+			res.OnEvicted = func(key string, val any) {
+				evicted = append(evicted, key)
+			}
+			_ = res.Set(context.Background(), "k1", "v1", time.Hour)
+			_ = res.Set(context.Background(), "k2", "v2", time.Minute)
+			_, err := res.Get(context.Background(), "k1")
+			assert.NoError(t, err)
+
+			err = res.Set(context.Background(), "k3", "v3", time.Minute)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantEvicted, evicted)
+			assert.Equal(t, 2, res.cnt)
+			assert.Len(t, res.Data, 2)
+		})
+	}
+}
+
+func TestMaxCntCache_EvictStaleVictim(t *testing.T) {
+	policy := eviction.NewFIFO()
+	//policy 中残留一个不在缓存中的 key
+	policy.Add("ghost")
+	res := NewMaxCntCache(1, local_cache.NewBuildInMapCache(1), MaxCntCacheWithEvictedCallback(),
+		MaxCntCacheWithPolicy(policy))
+	assert.NoError(t, res.Set(context.Background(), "k1", "v1", time.Minute))
+	assert.NoError(t, res.Set(context.Background(), "k2", "v2", time.Minute))
+	assert.Equal(t, 1, res.cnt)
+	assert.Len(t, res.Data, 1)
+	_, err := res.Get(context.Background(), "k2")
+	assert.NoError(t, err)
+}
+
+func TestMaxCntCache_CntWithoutCallback(t *testing.T) {
+	res := NewMaxCntCache(2, local_cache.NewBuildInMapCache(2))
+	_ = res.Set(context.Background(), "k1", "v1", time.Minute)
+	_ = res.Set(context.Background(), "k2", "v2", time.Minute)
+	assert.NoError(t, res.Delete(context.Background(), "k1"))
+	assert.Equal(t, 1, res.cnt)
+	assert.NoError(t, res.Set(context.Background(), "k3", "v3", time.Minute))
+	//默认拒绝写入
+	err := res.Set(context.Background(), "k4", "v4", time.Minute)
+	assert.ErrorIs(t, err, ErrKeyExceedMaxCnt)
+}
+
+func TestMaxCntCache_Reject(t *testing.T) {
+	res := NewMaxCntCache(1, local_cache.NewBuildInMapCache(1), MaxCntCacheWithReject())
+	_ = res.Set(context.Background(), "k1", "v1", time.Minute)
+	err := res.Set(context.Background(), "k2", "v2", time.Minute)
+	assert.True(t, errors.Is(err, ErrKeyExceedMaxCnt))
+}
+
+func TestMaxCntCache_SetAfterClose(t *testing.T) {
+	res := NewMaxCntCache(1, local_cache.NewBuildInMapCache(1), MaxCntCacheWithEvictOldest())
+	assert.NoError(t, res.Set(context.Background(), "k1", "v1", time.Minute))
+	assert.NoError(t, res.Close(context.Background()))
+	//关闭后写入不淘汰已有的 key，也不改变计数
+	assert.Equal(t, cache.ErrClosed, res.Set(context.Background(), "k2", "v2", time.Minute))
+	assert.Equal(t, 1, res.cnt)
+	assert.Contains(t, res.Data, "k1")
+	assert.NotContains(t, res.Data, "k2")
+}
@@ -0,0 +1,72 @@
+package max_cnt_cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/ac-zht/cache"
+	"sync"
+	"time"
+)
+
+// TypedMaxCntCache 泛型限制键数量的缓存装饰器
+type TypedMaxCntCache[K comparable, V any] struct {
+	cache.TypedCache[K, V]
+	max   int
+	keys  map[K]struct{}
+	mutex *sync.Mutex
+	fn    func(key K, val V)
+}
+
+func NewTypedMaxCntCache[K comparable, V any](max int, c cache.TypedCache[K, V]) *TypedMaxCntCache[K, V] {
+	res := &TypedMaxCntCache[K, V]{
+		TypedCache: c,
+		max:        max,
+		keys:       make(map[K]struct{}, max),
+		mutex:      &sync.Mutex{},
+		fn:         func(key K, val V) {},
+	}
+	c.OnEvicted(res.evicted)
+	return res
+}
+
+// Set 先占用计数再写入，避免持锁调用底层缓存与淘汰回调互相等待
+func (m *TypedMaxCntCache[K, V]) Set(ctx context.Context, key K, val V, expiration time.Duration) error {
+	m.mutex.Lock()
+	_, ok := m.keys[key]
+	if !ok {
+		if len(m.keys)+1 > m.max {
+			m.mutex.Unlock()
+			return fmt.Errorf("%w, key : %v", ErrKeyExceedMaxCnt, key)
+		}
+		m.keys[key] = struct{}{}
+	}
+	m.mutex.Unlock()
+	err := m.TypedCache.Set(ctx, key, val, expiration)
+	if err != nil && !ok {
+		m.mutex.Lock()
+		delete(m.keys, key)
+		m.mutex.Unlock()
+	}
+	return err
+}
+
+func (m *TypedMaxCntCache[K, V]) OnEvicted(fn func(key K, val V)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.fn = fn
+}
+
+// Cnt 当前键数量
+func (m *TypedMaxCntCache[K, V]) Cnt() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.keys)
+}
+
+func (m *TypedMaxCntCache[K, V]) evicted(key K, val V) {
+	m.mutex.Lock()
+	delete(m.keys, key)
+	fn := m.fn
+	m.mutex.Unlock()
+	fn(key, val)
+}
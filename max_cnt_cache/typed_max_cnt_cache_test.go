@@ -0,0 +1,74 @@
+package max_cnt_cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/ac-zht/cache/local_cache"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTypedMaxCntCache_Set(t *testing.T) {
+	testCase := []struct {
+		name      string
+		cache     func() *TypedMaxCntCache[int, string]
+		key       int
+		val       string
+		wantCnt   int
+		wantError error
+	}{
+		{
+			name: "set and add cnt",
+			cache: func() *TypedMaxCntCache[int, string] {
+				return NewTypedMaxCntCache[int, string](10, local_cache.NewTypedBuildInMapCache[int, string](10))
+			},
+			key:     1,
+			val:     "value",
+			wantCnt: 1,
+		},
+		{
+			name: "set and cnt unchanged",
+			cache: func() *TypedMaxCntCache[int, string] {
+				res := NewTypedMaxCntCache[int, string](10, local_cache.NewTypedBuildInMapCache[int, string](10))
+				_ = res.Set(context.Background(), 1, "value", time.Minute)
+				return res
+			},
+			key:     1,
+			val:     "value",
+			wantCnt: 1,
+		},
+		{
+			name: "set fail",
+			cache: func() *TypedMaxCntCache[int, string] {
+				res := NewTypedMaxCntCache[int, string](2, local_cache.NewTypedBuildInMapCache[int, string](2))
+				_ = res.Set(context.Background(), 1, "v1", time.Minute)
+				_ = res.Set(context.Background(), 2, "v2", time.Minute)
+				return res
+			},
+			key:       3,
+			val:       "v3",
+			wantError: fmt.Errorf("%w, key : %d", ErrKeyExceedMaxCnt, 3),
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cache()
+			err := c.Set(context.Background(), tc.key, tc.val, time.Minute)
+			assert.Equal(t, tc.wantError, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantCnt, c.Cnt())
+		})
+	}
+}
+
+func TestTypedMaxCntCache_Delete(t *testing.T) {
+	c := NewTypedMaxCntCache[int, string](1, local_cache.NewTypedBuildInMapCache[int, string](1))
+	assert.NoError(t, c.Set(context.Background(), 1, "v1", time.Minute))
+	assert.NoError(t, c.Delete(context.Background(), 1))
+	assert.Equal(t, 0, c.Cnt())
+	assert.NoError(t, c.Set(context.Background(), 2, "v2", time.Minute))
+	assert.Equal(t, 1, c.Cnt())
+}
@@ -2,8 +2,10 @@ package max_memory_cache
 
 import (
 	"context"
+	"fmt"
 	"github.com/ac-zht/cache"
-	"github.com/ac-zht/gotools/list"
+	"github.com/ac-zht/cache/eviction"
+	"github.com/ac-zht/cache/metrics"
 	"sync"
 	"time"
 )
@@ -13,68 +15,151 @@ type MaxMemoryCache struct {
 	max  int64
 	used int64
 
-	keys  *list.LinkedList[string]
-	mutex *sync.Mutex
+	//policy 决定内存不足时淘汰哪个 key，默认 LRU
+	policy eviction.Policy
+	mutex  *sync.Mutex
+	//policyMutex 保护 used 和 policy。淘汰回调可能在底层缓存的清理 goroutine 中执行，
+	//不能依赖 mutex，持有 policyMutex 期间不调用底层缓存，避免回调重入
+	policyMutex *sync.Mutex
+	closed      bool
+	stats       *metrics.Recorder
 }
 
-func NewMaxMemoryCache(max int64, cache cache.Cache) *MaxMemoryCache {
+type MaxMemoryCacheOption func(cache *MaxMemoryCache)
+
+func NewMaxMemoryCache(max int64, cache cache.Cache, opts ...MaxMemoryCacheOption) *MaxMemoryCache {
 	res := &MaxMemoryCache{
-		Cache: cache,
-		max:   max,
-		keys:  list.NewLinkedList[string](),
-		mutex: &sync.Mutex{},
+		Cache:       cache,
+		max:         max,
+		policy:      eviction.NewLRU(),
+		mutex:       &sync.Mutex{},
+		policyMutex: &sync.Mutex{},
+		stats:       metrics.NewRecorder(),
+	}
+	for _, opt := range opts {
+		opt(res)
 	}
 	res.Cache.OnEvicted(res.evicted)
 	return res
 }
 
+func MaxMemoryCacheWithPolicy(policy eviction.Policy) MaxMemoryCacheOption {
+	return func(cache *MaxMemoryCache) {
+		cache.policy = policy
+	}
+}
+
 func (m *MaxMemoryCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
+	if m.closed {
+		return cache.ErrClosed
+	}
 	//为了保证keys中key淘汰顺序
 	_, _ = m.Cache.LoadAndDelete(ctx, key)
-	for m.used+int64(len(val)) > m.max {
-		expKey, err := m.keys.Get(0)
-		if err != nil {
-			return err
+	for {
+		expKey, full, ok := m.victim(int64(len(val)))
+		if !full {
+			break
 		}
-		err = m.Cache.Delete(ctx, expKey)
+		if !ok {
+			return fmt.Errorf("cache: value exceed max memory, key : %s", key)
+		}
+		err := m.Cache.Delete(ctx, expKey)
 		if err != nil {
 			return err
 		}
+		m.stats.Evict(cache.EvictReasonCapacity)
+		//底层缓存中已不存在该 key 时不会触发回调，这里兜底移除，避免死循环
+		m.policyMutex.Lock()
+		m.policy.Remove(expKey)
+		m.policyMutex.Unlock()
 	}
 	err := m.Cache.Set(ctx, key, val, expiration)
 	if err == nil {
+		m.policyMutex.Lock()
 		m.used += int64(len(val))
-		_ = m.keys.Append(key)
+		m.policy.Add(key)
+		m.policyMutex.Unlock()
+		m.stats.Set()
 	}
 	return err
 }
 
+// victim 写入 size 字节后超出上限时返回需要淘汰的 key，full 为 false 表示无需淘汰
+func (m *MaxMemoryCache) victim(size int64) (key string, full bool, ok bool) {
+	m.policyMutex.Lock()
+	defer m.policyMutex.Unlock()
+	if m.used+size <= m.max {
+		return "", false, false
+	}
+	key, ok = m.policy.Victim()
+	return key, true, ok
+}
+
 func (m *MaxMemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
+	if m.closed {
+		return nil, cache.ErrClosed
+	}
 	val, err := m.Cache.Get(ctx, key)
 	if err == nil {
-		m.deleteKey(key)
-		_ = m.keys.Append(key)
+		m.policyMutex.Lock()
+		m.policy.Access(key)
+		m.policyMutex.Unlock()
+		m.stats.Hit()
 		return val, nil
 	}
+	m.stats.Miss()
 	return val, cache.ErrKeyNotFound
 }
 
 func (m *MaxMemoryCache) Delete(ctx context.Context, key string) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
+	if m.closed {
+		return cache.ErrClosed
+	}
+	m.stats.Delete()
 	return m.Cache.Delete(ctx, key)
 }
 
 func (m *MaxMemoryCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
+	if m.closed {
+		return nil, cache.ErrClosed
+	}
+	m.stats.Delete()
 	return m.Cache.LoadAndDelete(ctx, key)
 }
 
+// Close 关闭后的操作返回 cache.ErrClosed，底层缓存实现了 cache.Closer 时一并关闭
+func (m *MaxMemoryCache) Close(ctx context.Context) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.closed {
+		return cache.ErrClosed
+	}
+	m.closed = true
+	if c, ok := m.Cache.(cache.Closer); ok {
+		return c.Close(ctx)
+	}
+	return nil
+}
+
+// Stats 容量淘汰只统计本层触发的淘汰，过期等原因的移除由底层缓存统计，Bytes 为当前已用内存
+func (m *MaxMemoryCache) Stats() metrics.Stats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	res := m.stats.Snapshot()
+	m.policyMutex.Lock()
+	res.Bytes = m.used
+	m.policyMutex.Unlock()
+	return res
+}
+
 func (m *MaxMemoryCache) OnEvicted(fn func(key string, val []byte)) {
 	m.Cache.OnEvicted(func(key string, val []byte) {
 		m.evicted(key, val)
@@ -83,16 +168,8 @@ func (m *MaxMemoryCache) OnEvicted(fn func(key string, val []byte)) {
 }
 
 func (m *MaxMemoryCache) evicted(key string, val []byte) {
+	m.policyMutex.Lock()
+	defer m.policyMutex.Unlock()
 	m.used -= int64(len(val))
-	m.deleteKey(key)
-}
-
-func (m *MaxMemoryCache) deleteKey(key string) {
-	keys := m.keys.AsSlice()
-	for i, val := range keys {
-		if val == key {
-			_, _ = m.keys.Delete(i)
-			return
-		}
-	}
+	m.policy.Remove(key)
 }
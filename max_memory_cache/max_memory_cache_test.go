@@ -3,9 +3,13 @@ package max_memory_cache
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/ac-zht/cache"
-	"github.com/ac-zht/gotools/list"
+	"github.com/ac-zht/cache/eviction"
+	"github.com/ac-zht/cache/local_cache"
 	"github.com/stretchr/testify/assert"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 )
@@ -44,7 +48,7 @@ func TestMaxMemoryCache_Set(t *testing.T) {
 					},
 				})
 				res.used = 6
-				res.keys = list.NewLinkedListOf[string]([]string{"k", "key"})
+				initKeys(res, "k", "key")
 				return res
 			},
 			key:      "key",
@@ -63,7 +67,7 @@ func TestMaxMemoryCache_Set(t *testing.T) {
 					},
 				})
 				res.used = 6
-				res.keys = list.NewLinkedListOf[string]([]string{"k", "key"})
+				initKeys(res, "k", "key")
 				return res
 			},
 			key:      "key",
@@ -81,7 +85,7 @@ func TestMaxMemoryCache_Set(t *testing.T) {
 					},
 				})
 				res.used = 5
-				res.keys = list.NewLinkedListOf[string]([]string{"key"})
+				initKeys(res, "key")
 				return res
 			},
 			key:      "this key",
@@ -101,7 +105,7 @@ func TestMaxMemoryCache_Set(t *testing.T) {
 					},
 				})
 				res.used = 6
-				res.keys = list.NewLinkedListOf[string]([]string{"k1", "k2", "k3"})
+				initKeys(res, "k1", "k2", "k3")
 				return res
 			},
 			key:      "k4",
@@ -115,7 +119,7 @@ func TestMaxMemoryCache_Set(t *testing.T) {
 			m := tc.m()
 			err := m.Set(context.Background(), tc.key, tc.value, time.Minute)
 			assert.Equal(t, tc.wantError, err)
-			assert.Equal(t, tc.wantKeys, m.keys.AsSlice())
+			assert.Equal(t, tc.wantKeys, keysOf(m))
 			assert.Equal(t, tc.wantUsed, m.used)
 		})
 	}
@@ -141,7 +145,7 @@ func TestMaxMemoryCache_Get(t *testing.T) {
 					},
 				})
 				res.used = 6
-				res.keys = list.NewLinkedListOf[string]([]string{"k1", "k2", "k3"})
+				initKeys(res, "k1", "k2", "k3")
 				return res
 			},
 			key:       "k4",
@@ -158,7 +162,7 @@ func TestMaxMemoryCache_Get(t *testing.T) {
 					},
 				})
 				res.used = 6
-				res.keys = list.NewLinkedListOf[string]([]string{"k1", "k2", "k3"})
+				initKeys(res, "k1", "k2", "k3")
 				return res
 			},
 			key:      "k1",
@@ -175,7 +179,7 @@ func TestMaxMemoryCache_Get(t *testing.T) {
 				return
 			}
 			assert.Equal(t, tc.wantVal, val)
-			assert.Equal(t, tc.wantKeys, m.keys.AsSlice())
+			assert.Equal(t, tc.wantKeys, keysOf(m))
 		})
 	}
 }
@@ -200,7 +204,7 @@ func TestMaxMemoryCache_Delete(t *testing.T) {
 					},
 				})
 				res.used = 6
-				res.keys = list.NewLinkedListOf[string]([]string{"k1", "k2", "k3"})
+				initKeys(res, "k1", "k2", "k3")
 				return res
 			},
 			key:      "k4",
@@ -218,7 +222,7 @@ func TestMaxMemoryCache_Delete(t *testing.T) {
 					},
 				})
 				res.used = 6
-				res.keys = list.NewLinkedListOf[string]([]string{"k1", "k2", "k3"})
+				initKeys(res, "k1", "k2", "k3")
 				return res
 			},
 			key:      "k2",
@@ -235,7 +239,7 @@ func TestMaxMemoryCache_Delete(t *testing.T) {
 				return
 			}
 			assert.Equal(t, tc.wantUsed, m.used)
-			assert.Equal(t, tc.wantKeys, m.keys.AsSlice())
+			assert.Equal(t, tc.wantKeys, keysOf(m))
 		})
 	}
 }
@@ -261,7 +265,7 @@ func TestMaxMemoryCache_LoadAndDelete(t *testing.T) {
 					},
 				})
 				res.used = 6
-				res.keys = list.NewLinkedListOf[string]([]string{"k1", "k2", "k3"})
+				initKeys(res, "k1", "k2", "k3")
 				return res
 			},
 			key:       "k4",
@@ -280,7 +284,7 @@ func TestMaxMemoryCache_LoadAndDelete(t *testing.T) {
 					},
 				})
 				res.used = 6
-				res.keys = list.NewLinkedListOf[string]([]string{"k1", "k2", "k3"})
+				initKeys(res, "k1", "k2", "k3")
 				return res
 			},
 			key:      "k2",
@@ -299,11 +303,57 @@ func TestMaxMemoryCache_LoadAndDelete(t *testing.T) {
 			}
 			assert.Equal(t, tc.wantVal, val)
 			assert.Equal(t, tc.wantUsed, m.used)
-			assert.Equal(t, tc.wantKeys, m.keys.AsSlice())
+			assert.Equal(t, tc.wantKeys, keysOf(m))
 		})
 	}
 }
 
+func TestMaxMemoryCache_WithPolicy(t *testing.T) {
+	m := NewMaxMemoryCache(4, &mockCache{
+		data: map[string][]byte{},
+	}, MaxMemoryCacheWithPolicy(eviction.NewFIFO()))
+	ctx := context.Background()
+	assert.NoError(t, m.Set(ctx, "k1", []byte("v1"), time.Minute))
+	assert.NoError(t, m.Set(ctx, "k2", []byte("v2"), time.Minute))
+	_, err := m.Get(ctx, "k1")
+	assert.NoError(t, err)
+	//FIFO 不受访问影响，仍然淘汰最早写入的 k1
+	assert.NoError(t, m.Set(ctx, "k3", []byte("v3"), time.Minute))
+	assert.Equal(t, []string{"k2", "k3"}, m.policy.(*eviction.FIFO).Keys())
+	assert.Equal(t, int64(4), m.used)
+}
+
+func TestMaxMemoryCache_Stats(t *testing.T) {
+	m := NewMaxMemoryCache(4, &mockCache{
+		data: map[string][]byte{},
+	})
+	ctx := context.Background()
+	assert.NoError(t, m.Set(ctx, "k1", []byte("v1"), time.Minute))
+	assert.NoError(t, m.Set(ctx, "k2", []byte("v2"), time.Minute))
+	assert.NoError(t, m.Set(ctx, "k3", []byte("v3"), time.Minute))
+	_, _ = m.Get(ctx, "k1")
+	_, _ = m.Get(ctx, "k3")
+	assert.NoError(t, m.Delete(ctx, "k2"))
+
+	stats := m.Stats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(3), stats.Sets)
+	assert.Equal(t, uint64(1), stats.Deletes)
+	assert.Equal(t, map[cache.EvictReason]uint64{cache.EvictReasonCapacity: 1}, stats.Evictions)
+	assert.Equal(t, int64(2), stats.Bytes)
+}
+
+func initKeys(m *MaxMemoryCache, keys ...string) {
+	for _, key := range keys {
+		m.policy.Add(key)
+	}
+}
+
+func keysOf(m *MaxMemoryCache) []string {
+	return m.policy.(*eviction.LRU).Keys()
+}
+
 type mockCache struct {
 	cache.Cache
 	data map[string][]byte
@@ -345,3 +395,89 @@ func (m *mockCache) LoadAndDelete(ctx context.Context, key string) ([]byte, erro
 func (m *mockCache) OnEvicted(fn func(key string, val []byte)) {
 	m.fn = fn
 }
+
+func BenchmarkMaxMemoryCache_Get(b *testing.B) {
+	for _, n := range []int{10000, 100000, 1000000} {
+		b.Run(fmt.Sprintf("keys_%d", n), func(b *testing.B) {
+			m := newBenchMaxMemoryCache(n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = m.Get(context.Background(), strconv.Itoa(i%n))
+			}
+		})
+	}
+}
+
+func BenchmarkMaxMemoryCache_Set(b *testing.B) {
+	for _, n := range []int{10000, 100000, 1000000} {
+		b.Run(fmt.Sprintf("keys_%d", n), func(b *testing.B) {
+			m := newBenchMaxMemoryCache(n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = m.Set(context.Background(), strconv.Itoa(i%(2*n)), []byte("v"), time.Minute)
+			}
+		})
+	}
+}
+
+// newBenchMaxMemoryCache 构造已写满 n 个 key 的缓存，之后的 Set 新 key 都会触发淘汰
+func newBenchMaxMemoryCache(n int) *MaxMemoryCache {
+	m := NewMaxMemoryCache(int64(n), &mockCache{
+		data: make(map[string][]byte, n),
+	})
+	for i := 0; i < n; i++ {
+		_ = m.Set(context.Background(), strconv.Itoa(i), []byte("v"), time.Minute)
+	}
+	return m
+}
+
+func TestMaxMemoryCache_Close(t *testing.T) {
+	inner := &closableMockCache{mockCache: mockCache{data: map[string][]byte{}}}
+	m := NewMaxMemoryCache(10, inner)
+	assert.NoError(t, m.Close(context.Background()))
+	assert.True(t, inner.closed)
+	assert.Equal(t, cache.ErrClosed, m.Close(context.Background()))
+	assert.Equal(t, cache.ErrClosed, m.Set(context.Background(), "key", []byte("value"), time.Minute))
+	_, err := m.Get(context.Background(), "key")
+	assert.Equal(t, cache.ErrClosed, err)
+}
+
+type closableMockCache struct {
+	mockCache
+	closed bool
+}
+
+func (m *closableMockCache) Close(ctx context.Context) error {
+	m.closed = true
+	return nil
+}
+
+func TestMaxMemoryCache_ConcurrentEvict(t *testing.T) {
+	//淘汰回调可能在底层缓存的清理 goroutine 中执行，这里绕过 MaxMemoryCache 直接删除底层缓存来模拟
+	inner := local_cache.NewBytesCache(1000)
+	m := NewMaxMemoryCache(1024, inner)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		keys := make(chan string, 10)
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			defer close(keys)
+			for j := 0; j < 200; j++ {
+				key := strconv.Itoa(i*1000 + j)
+				_ = m.Set(context.Background(), key, []byte("value"), time.Minute)
+				_, _ = m.Get(context.Background(), key)
+				keys <- key
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for key := range keys {
+				_ = inner.Delete(context.Background(), key)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(0), m.used)
+	assert.Equal(t, 0, m.policy.Len())
+}
@@ -0,0 +1,217 @@
+package memcache_cache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrMalformedKey key 超过 250 字节或包含空白、控制字符
+	ErrMalformedKey = errors.New("memcache: malformed key")
+	// ErrServer 服务端返回 ERROR、CLIENT_ERROR 或 SERVER_ERROR
+	ErrServer = errors.New("memcache: server error")
+	// ErrCASConflict LoadAndDelete 重试多次后值仍在被其他客户端修改
+	ErrCASConflict = errors.New("memcache: cas conflict")
+
+	//errProtocol 响应无法解析，连接上可能残留未读数据，不能复用
+	errProtocol = errors.New("memcache: protocol error")
+	errNotFound = errors.New("memcache: not found")
+)
+
+// textConn 基于 memcached 文本协议的单个连接，非并发安全
+type textConn struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+}
+
+func newTextConn(conn net.Conn) *textConn {
+	return &textConn{
+		conn: conn,
+		rw:   bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn)),
+	}
+}
+
+// maxItemSize memcached 允许的最大 value，-I 参数的上限为 1GB
+const maxItemSize = 1 << 30
+
+type value struct {
+	data []byte
+	cas  uint64
+}
+
+// get 发送 get/gets，key 不存在时返回 errNotFound
+func (c *textConn) get(ctx context.Context, cmd string, key string) (value, error) {
+	if err := c.begin(ctx, "%s %s\r\n", cmd, key); err != nil {
+		return value{}, err
+	}
+	line, err := c.readLine()
+	if err != nil {
+		return value{}, err
+	}
+	if bytes.Equal(line, []byte("END")) {
+		return value{}, errNotFound
+	}
+	//VALUE <key> <flags> <bytes> [<cas unique>]
+	fields := bytes.Fields(line)
+	if len(fields) < 4 || string(fields[0]) != "VALUE" {
+		return value{}, c.serverError(line)
+	}
+	n, err := strconv.Atoi(string(fields[3]))
+	if err != nil || n < 0 || n > maxItemSize {
+		return value{}, c.protocolError(line)
+	}
+	var res value
+	if len(fields) == 5 {
+		if res.cas, err = strconv.ParseUint(string(fields[4]), 10, 64); err != nil {
+			return value{}, c.protocolError(line)
+		}
+	}
+	buf := make([]byte, n+2)
+	if _, err = io.ReadFull(c.rw, buf); err != nil {
+		return value{}, err
+	}
+	res.data = buf[:n]
+	if line, err = c.readLine(); err != nil {
+		return value{}, err
+	}
+	if !bytes.Equal(line, []byte("END")) {
+		return value{}, c.protocolError(line)
+	}
+	return res, nil
+}
+
+func (c *textConn) set(ctx context.Context, key string, val []byte, exptime int64) error {
+	if err := c.begin(ctx, "set %s 0 %d %d\r\n%s\r\n", key, exptime, len(val), val); err != nil {
+		return err
+	}
+	return c.expect("STORED")
+}
+
+// cas 值未被修改时才写入，否则返回 ErrCASConflict
+func (c *textConn) cas(ctx context.Context, key string, val []byte, exptime int64, cas uint64) error {
+	if err := c.begin(ctx, "cas %s 0 %d %d %d\r\n%s\r\n", key, exptime, len(val), cas, val); err != nil {
+		return err
+	}
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	switch string(line) {
+	case "STORED":
+		return nil
+	case "EXISTS":
+		return ErrCASConflict
+	case "NOT_FOUND":
+		return errNotFound
+	default:
+		return c.serverError(line)
+	}
+}
+
+func (c *textConn) begin(ctx context.Context, format string, args ...any) error {
+	dl, _ := ctx.Deadline()
+	if err := c.conn.SetDeadline(dl); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(c.rw, format, args...); err != nil {
+		return err
+	}
+	return c.rw.Flush()
+}
+
+func (c *textConn) expect(want string) error {
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	if string(line) != want {
+		return c.serverError(line)
+	}
+	return nil
+}
+
+func (c *textConn) readLine() ([]byte, error) {
+	line, err := c.rw.ReadSlice('\n')
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(line, []byte("\r\n")), nil
+}
+
+// serverError 只有完整读取的 ERROR、CLIENT_ERROR、SERVER_ERROR 行视为服务端错误，其余都是协议错误
+func (c *textConn) serverError(line []byte) error {
+	if bytes.Equal(line, []byte("ERROR")) ||
+		bytes.HasPrefix(line, []byte("CLIENT_ERROR ")) ||
+		bytes.HasPrefix(line, []byte("SERVER_ERROR ")) {
+		return fmt.Errorf("%w: %s", ErrServer, line)
+	}
+	return c.protocolError(line)
+}
+
+func (c *textConn) protocolError(line []byte) error {
+	return fmt.Errorf("%w: %s", errProtocol, line)
+}
+
+func (c *textConn) Close() error {
+	return c.conn.Close()
+}
+
+// pool 单个服务端的连接池，空闲连接数量不超过 maxIdle
+type pool struct {
+	addr   string
+	dial   func(ctx context.Context, addr string) (*textConn, error)
+	idle   chan *textConn
+	closed int32
+}
+
+func newPool(addr string, maxIdle int, dial func(ctx context.Context, addr string) (*textConn, error)) *pool {
+	return &pool{
+		addr: addr,
+		dial: dial,
+		idle: make(chan *textConn, maxIdle),
+	}
+}
+
+func (p *pool) get(ctx context.Context) (*textConn, error) {
+	select {
+	case c := <-p.idle:
+		return c, nil
+	default:
+		return p.dial(ctx, p.addr)
+	}
+}
+
+// put 未命中、冲突和完整读取的服务端错误不影响连接复用，其余错误（包括协议错误）关闭连接
+func (p *pool) put(c *textConn, err error) {
+	if (err != nil && !errors.Is(err, errNotFound) && !errors.Is(err, ErrCASConflict) && !errors.Is(err, ErrServer)) ||
+		atomic.LoadInt32(&p.closed) == 1 {
+		_ = c.Close()
+		return
+	}
+	_ = c.conn.SetDeadline(time.Time{})
+	select {
+	case p.idle <- c:
+	default:
+		_ = c.Close()
+	}
+}
+
+func (p *pool) close() {
+	atomic.StoreInt32(&p.closed, 1)
+	for {
+		select {
+		case c := <-p.idle:
+			_ = c.Close()
+		default:
+			return
+		}
+	}
+}
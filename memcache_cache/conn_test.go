@@ -0,0 +1,86 @@
+package memcache_cache
+
+import (
+	"bufio"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+)
+
+func TestPool_PutAfterGet(t *testing.T) {
+	testCase := []struct {
+		name      string
+		reply     string
+		wantError error
+		wantIdle  int
+	}{
+		{
+			name:     "hit",
+			reply:    "VALUE key 0 5\r\nvalue\r\nEND\r\n",
+			wantIdle: 1,
+		},
+		{
+			name:      "not found",
+			reply:     "END\r\n",
+			wantError: errNotFound,
+			wantIdle:  1,
+		},
+		{
+			name:      "server error",
+			reply:     "SERVER_ERROR out of memory\r\n",
+			wantError: ErrServer,
+			wantIdle:  1,
+		},
+		{
+			name:      "malformed header",
+			reply:     "VALUE key 0 abc\r\nvalue\r\nEND\r\n",
+			wantError: errProtocol,
+		},
+		{
+			name:      "missing end",
+			reply:     "VALUE key 0 5\r\nvalue\r\nVALUE key 0 5\r\nvalue\r\nEND\r\n",
+			wantError: errProtocol,
+		},
+		{
+			name:      "negative length",
+			reply:     "VALUE key 0 -5\r\nvalue\r\nEND\r\n",
+			wantError: errProtocol,
+		},
+		{
+			name:      "negative one length",
+			reply:     "VALUE key 0 -1\r\nEND\r\n",
+			wantError: errProtocol,
+		},
+		{
+			name:      "too large length",
+			reply:     "VALUE key 0 9999999999\r\n",
+			wantError: errProtocol,
+		},
+		{
+			name:      "unexpected line",
+			reply:     "STORED\r\n",
+			wantError: errProtocol,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer server.Close()
+			go func() {
+				r := bufio.NewReader(server)
+				if _, err := r.ReadString('\n'); err != nil {
+					return
+				}
+				_, _ = server.Write([]byte(tc.reply))
+			}()
+			p := newPool("", 1, nil)
+			c := newTextConn(client)
+			_, err := c.get(context.Background(), "get", "key")
+			assert.ErrorIs(t, err, tc.wantError)
+			p.put(c, err)
+			assert.Len(t, p.idle, tc.wantIdle)
+			p.close()
+		})
+	}
+}
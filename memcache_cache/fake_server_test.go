@@ -0,0 +1,154 @@
+package memcache_cache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMemcache 进程内的 memcached 文本协议服务端，只实现 get/gets/set/cas
+type fakeMemcache struct {
+	ln    net.Listener
+	mutex *sync.Mutex
+	data  map[string]*fakeItem
+	cas   uint64
+}
+
+type fakeItem struct {
+	val      []byte
+	deadline time.Time
+	cas      uint64
+}
+
+func newFakeMemcache(t *testing.T) *fakeMemcache {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeMemcache{
+		ln:    ln,
+		mutex: &sync.Mutex{},
+		data:  map[string]*fakeItem{},
+	}
+	go s.serve()
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+	return s
+}
+
+func (s *fakeMemcache) addr() string {
+	return s.ln.Addr().String()
+}
+
+// len 未过期的 key 数量
+func (s *fakeMemcache) len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	res := 0
+	for key := range s.data {
+		if _, ok := s.lookup(key); ok {
+			res++
+		}
+	}
+	return res
+}
+
+func (s *fakeMemcache) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeMemcache) handle(conn net.Conn) {
+	defer conn.Close()
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			return
+		}
+		if err = s.exec(rw, fields); err != nil {
+			return
+		}
+		if err = rw.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeMemcache) exec(rw *bufio.ReadWriter, fields []string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	switch fields[0] {
+	case "get", "gets":
+		if itm, ok := s.lookup(fields[1]); ok {
+			if fields[0] == "gets" {
+				_, _ = fmt.Fprintf(rw, "VALUE %s 0 %d %d\r\n", fields[1], len(itm.val), itm.cas)
+			} else {
+				_, _ = fmt.Fprintf(rw, "VALUE %s 0 %d\r\n", fields[1], len(itm.val))
+			}
+			_, _ = rw.Write(itm.val)
+			_, _ = rw.WriteString("\r\n")
+		}
+		_, _ = rw.WriteString("END\r\n")
+	case "set", "cas":
+		exp, _ := strconv.ParseInt(fields[3], 10, 64)
+		n, _ := strconv.Atoi(fields[4])
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rw, buf); err != nil {
+			return err
+		}
+		if fields[0] == "cas" {
+			cas, _ := strconv.ParseUint(fields[5], 10, 64)
+			itm, ok := s.lookup(fields[1])
+			if !ok {
+				_, _ = rw.WriteString("NOT_FOUND\r\n")
+				return nil
+			}
+			if itm.cas != cas {
+				_, _ = rw.WriteString("EXISTS\r\n")
+				return nil
+			}
+		}
+		s.cas++
+		itm := &fakeItem{val: buf[:n], cas: s.cas}
+		switch {
+		case exp < 0:
+			itm.deadline = time.Now().Add(-time.Second)
+		case exp > 0:
+			itm.deadline = time.Now().Add(time.Duration(exp) * time.Second)
+		}
+		s.data[fields[1]] = itm
+		_, _ = rw.WriteString("STORED\r\n")
+	default:
+		_, _ = rw.WriteString("ERROR\r\n")
+	}
+	return nil
+}
+
+func (s *fakeMemcache) lookup(key string) (*fakeItem, bool) {
+	itm, ok := s.data[key]
+	if !ok {
+		return nil, false
+	}
+	if !itm.deadline.IsZero() && itm.deadline.Before(time.Now()) {
+		delete(s.data, key)
+		return nil, false
+	}
+	return itm, true
+}
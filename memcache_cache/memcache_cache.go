@@ -0,0 +1,208 @@
+package memcache_cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ac-zht/cache"
+	"net"
+	"sync"
+	"time"
+)
+
+var _ cache.Cache = &MemcacheCache{}
+
+// relativeExpirationLimit memcached 把超过 30 天的过期时间当作 unix 时间戳
+const relativeExpirationLimit = 30 * 24 * time.Hour
+
+type MemcacheCacheOption func(cache *MemcacheCache)
+
+// MemcacheCache 基于 memcached 文本协议实现的缓存，多个服务端之间按一致性哈希分布 key。
+// memcached 不会通知过期和淘汰，OnEvicted 只在 Delete、LoadAndDelete 时触发
+type MemcacheCache struct {
+	ring        *ring
+	pools       map[string]*pool
+	dialTimeout time.Duration
+	maxIdle     int
+	replicas    int
+	maxRetry    int
+
+	mutex     *sync.RWMutex
+	onEvicted func(key string, val []byte)
+	closed    bool
+}
+
+func NewMemcacheCache(addrs []string, opts ...MemcacheCacheOption) *MemcacheCache {
+	res := &MemcacheCache{
+		pools:       make(map[string]*pool, len(addrs)),
+		dialTimeout: time.Second * 3,
+		maxIdle:     16,
+		replicas:    160,
+		maxRetry:    3,
+		mutex:       &sync.RWMutex{},
+		onEvicted:   func(key string, val []byte) {},
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+	res.ring = newRing(res.replicas, addrs...)
+	for _, addr := range addrs {
+		res.pools[addr] = newPool(addr, res.maxIdle, res.dial)
+	}
+	return res
+}
+
+func MemcacheCacheWithDialTimeout(timeout time.Duration) MemcacheCacheOption {
+	return func(cache *MemcacheCache) {
+		cache.dialTimeout = timeout
+	}
+}
+
+// MemcacheCacheWithMaxIdle 每个服务端保留的空闲连接数量
+func MemcacheCacheWithMaxIdle(maxIdle int) MemcacheCacheOption {
+	return func(cache *MemcacheCache) {
+		cache.maxIdle = maxIdle
+	}
+}
+
+// MemcacheCacheWithReplicas 每个服务端在哈希环上的虚拟节点数量
+func MemcacheCacheWithReplicas(replicas int) MemcacheCacheOption {
+	return func(cache *MemcacheCache) {
+		cache.replicas = replicas
+	}
+}
+
+func (c *MemcacheCache) Get(ctx context.Context, key string) ([]byte, error) {
+	var val value
+	err := c.do(ctx, key, func(conn *textConn) error {
+		var err error
+		val, err = conn.get(ctx, "get", key)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return val.data, nil
+}
+
+func (c *MemcacheCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
+	return c.do(ctx, key, func(conn *textConn) error {
+		return conn.set(ctx, key, val, exptime(expiration))
+	})
+}
+
+// Delete 借助 LoadAndDelete 拿到被删除的值以便触发回调
+func (c *MemcacheCache) Delete(ctx context.Context, key string) error {
+	_, err := c.LoadAndDelete(ctx, key)
+	if errors.Is(err, cache.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+// LoadAndDelete 先 gets 读取值和 cas，再用 cas 写入一个立即过期的空值完成删除，
+// 期间值被其他客户端修改时重试，至少执行一次，重试用尽后返回 ErrCASConflict
+func (c *MemcacheCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	var val value
+	err := c.do(ctx, key, func(conn *textConn) error {
+		for i := 1; ; i++ {
+			var err error
+			val, err = conn.get(ctx, "gets", key)
+			if err != nil {
+				return err
+			}
+			err = conn.cas(ctx, key, nil, -1, val.cas)
+			if !errors.Is(err, ErrCASConflict) || i >= c.maxRetry {
+				return err
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.mutex.RLock()
+	fn := c.onEvicted
+	c.mutex.RUnlock()
+	fn(key, val.data)
+	return val.data, nil
+}
+
+func (c *MemcacheCache) OnEvicted(fn func(key string, val []byte)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.onEvicted = fn
+}
+
+// Close 关闭所有连接池，之后的操作返回 cache.ErrClosed
+func (c *MemcacheCache) Close(ctx context.Context) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return cache.ErrClosed
+	}
+	c.closed = true
+	for _, p := range c.pools {
+		p.close()
+	}
+	return nil
+}
+
+// do 选出 key 所在服务端的连接执行 fn，未命中转换为 cache.ErrKeyNotFound
+func (c *MemcacheCache) do(ctx context.Context, key string, fn func(conn *textConn) error) error {
+	if !validKey(key) {
+		return fmt.Errorf("%w, key: %q", ErrMalformedKey, key)
+	}
+	c.mutex.RLock()
+	closed := c.closed
+	c.mutex.RUnlock()
+	if closed {
+		return cache.ErrClosed
+	}
+	p, ok := c.pools[c.ring.get(key)]
+	if !ok {
+		return errors.New("memcache: no server available")
+	}
+	conn, err := p.get(ctx)
+	if err != nil {
+		return err
+	}
+	err = fn(conn)
+	p.put(conn, err)
+	if errors.Is(err, errNotFound) {
+		return fmt.Errorf("%w, key: %s", cache.ErrKeyNotFound, key)
+	}
+	return err
+}
+
+func (c *MemcacheCache) dial(ctx context.Context, addr string) (*textConn, error) {
+	dialer := &net.Dialer{Timeout: c.dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return newTextConn(conn), nil
+}
+
+// exptime 转换为 memcached 的过期时间：0 表示永不过期，不足一秒向上取整，超过 30 天使用 unix 时间戳
+func exptime(expiration time.Duration) int64 {
+	if expiration <= 0 {
+		return 0
+	}
+	seconds := int64((expiration + time.Second - 1) / time.Second)
+	if expiration > relativeExpirationLimit {
+		return time.Now().Unix() + seconds
+	}
+	return seconds
+}
+
+func validKey(key string) bool {
+	if len(key) == 0 || len(key) > 250 {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package memcache_cache
+
+import (
+	"context"
+	"github.com/ac-zht/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMemcacheCache_Get(t *testing.T) {
+	testCase := []struct {
+		name      string
+		before    func(c *MemcacheCache)
+		key       string
+		wantVal   []byte
+		wantError error
+	}{
+		{
+			name: "get",
+			before: func(c *MemcacheCache) {
+				_ = c.Set(context.Background(), "key", []byte("value"), time.Minute)
+			},
+			key:     "key",
+			wantVal: []byte("value"),
+		},
+		{
+			name:      "not exist",
+			before:    func(c *MemcacheCache) {},
+			key:       "key",
+			wantError: cache.ErrKeyNotFound,
+		},
+		{
+			name:      "malformed key",
+			before:    func(c *MemcacheCache) {},
+			key:       "a key",
+			wantError: ErrMalformedKey,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newFakeMemcache(t)
+			c := NewMemcacheCache([]string{s.addr()})
+			defer c.Close(context.Background())
+			tc.before(c)
+			val, err := c.Get(context.Background(), tc.key)
+			assert.ErrorIs(t, err, tc.wantError)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantVal, val)
+		})
+	}
+}
+
+func TestMemcacheCache_LoadAndDelete(t *testing.T) {
+	s := newFakeMemcache(t)
+	c := NewMemcacheCache([]string{s.addr()})
+	defer c.Close(context.Background())
+	evicted := map[string][]byte{}
+	c.OnEvicted(func(key string, val []byte) {
+		evicted[key] = val
+	})
+	require.NoError(t, c.Set(context.Background(), "k1", []byte("v1"), time.Minute))
+	require.NoError(t, c.Set(context.Background(), "k2", []byte("v2"), time.Minute))
+
+	val, err := c.LoadAndDelete(context.Background(), "k1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v1"), val)
+	_, err = c.LoadAndDelete(context.Background(), "k1")
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+	assert.NoError(t, c.Delete(context.Background(), "k2"))
+	assert.NoError(t, c.Delete(context.Background(), "k3"))
+	assert.Equal(t, map[string][]byte{"k1": []byte("v1"), "k2": []byte("v2")}, evicted)
+	assert.Equal(t, 0, s.len())
+}
+
+func TestMemcacheCache_LoadAndDeleteWithoutRetry(t *testing.T) {
+	s := newFakeMemcache(t)
+	c := NewMemcacheCache([]string{s.addr()})
+	defer c.Close(context.Background())
+	//不重试时也至少删除一次
+	c.maxRetry = 0
+	evicted := map[string][]byte{}
+	c.OnEvicted(func(key string, val []byte) {
+		evicted[key] = val
+	})
+	require.NoError(t, c.Set(context.Background(), "key", []byte("value"), time.Minute))
+	val, err := c.LoadAndDelete(context.Background(), "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), val)
+	_, err = c.LoadAndDelete(context.Background(), "key")
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+	assert.Equal(t, map[string][]byte{"key": []byte("value")}, evicted)
+}
+
+func TestMemcacheCache_MultiServer(t *testing.T) {
+	servers := []*fakeMemcache{newFakeMemcache(t), newFakeMemcache(t), newFakeMemcache(t)}
+	addrs := make([]string, 0, len(servers))
+	for _, s := range servers {
+		addrs = append(addrs, s.addr())
+	}
+	c := NewMemcacheCache(addrs, MemcacheCacheWithMaxIdle(2))
+	defer c.Close(context.Background())
+	for i := 0; i < 300; i++ {
+		require.NoError(t, c.Set(context.Background(), strconv.Itoa(i), []byte(strconv.Itoa(i)), time.Minute))
+	}
+	total := 0
+	for _, s := range servers {
+		assert.Greater(t, s.len(), 0)
+		total += s.len()
+	}
+	assert.Equal(t, 300, total)
+	for i := 0; i < 300; i++ {
+		val, err := c.Get(context.Background(), strconv.Itoa(i))
+		require.NoError(t, err)
+		assert.Equal(t, []byte(strconv.Itoa(i)), val)
+	}
+}
+
+func TestMemcacheCache_Close(t *testing.T) {
+	s := newFakeMemcache(t)
+	c := NewMemcacheCache([]string{s.addr()})
+	require.NoError(t, c.Close(context.Background()))
+	assert.Equal(t, cache.ErrClosed, c.Close(context.Background()))
+	_, err := c.Get(context.Background(), "key")
+	assert.Equal(t, cache.ErrClosed, err)
+}
+
+func TestExptime(t *testing.T) {
+	assert.Equal(t, int64(0), exptime(0))
+	assert.Equal(t, int64(1), exptime(time.Millisecond))
+	assert.Equal(t, int64(60), exptime(time.Minute))
+	assert.InDelta(t, time.Now().Add(relativeExpirationLimit*2).Unix(), exptime(relativeExpirationLimit*2), 1)
+}
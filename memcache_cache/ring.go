@@ -0,0 +1,47 @@
+package memcache_cache
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+)
+
+// ring 一致性哈希环，每个节点对应 replicas 个虚拟节点，增删节点时只有少量 key 需要迁移
+type ring struct {
+	replicas int
+	hashes   []uint32
+	nodes    map[uint32]string
+}
+
+func newRing(replicas int, nodes ...string) *ring {
+	r := &ring{
+		replicas: replicas,
+		nodes:    make(map[uint32]string, replicas*len(nodes)),
+	}
+	for _, node := range nodes {
+		for i := 0; i < replicas; i++ {
+			h := crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + "-" + node))
+			r.hashes = append(r.hashes, h)
+			r.nodes[h] = node
+		}
+	}
+	sort.Slice(r.hashes, func(i, j int) bool {
+		return r.hashes[i] < r.hashes[j]
+	})
+	return r
+}
+
+// get 顺时针找到第一个不小于 key 哈希值的虚拟节点
+func (r *ring) get(key string) string {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(r.hashes), func(i int) bool {
+		return r.hashes[i] >= h
+	})
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.nodes[r.hashes[i]]
+}
@@ -0,0 +1,28 @@
+package memcache_cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	r := newRing(160, "a", "b", "c")
+	before := make(map[string]string, 1000)
+	cnt := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		before[key] = r.get(key)
+		cnt[before[key]]++
+	}
+	assert.Len(t, cnt, 3)
+
+	//新增节点后，只有分配到新节点的 key 发生迁移
+	r = newRing(160, "a", "b", "c", "d")
+	for key, node := range before {
+		if after := r.get(key); after != node {
+			assert.Equal(t, "d", after)
+		}
+	}
+	assert.Equal(t, "", newRing(160).get("key"))
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ac-zht/cache"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus 以 Prometheus 文本格式输出统计，stats 的 key 作为 cache 标签
+func WritePrometheus(w io.Writer, stats map[string]Stats) error {
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := &bytes.Buffer{}
+	counter := func(metric, help string, fn func(s Stats) uint64) {
+		header(buf, metric, help, "counter")
+		for _, name := range names {
+			fmt.Fprintf(buf, "%s{cache=\"%s\"} %d\n", metric, escape(name), fn(stats[name]))
+		}
+	}
+	counter("cache_hits_total", "Number of cache hits.", func(s Stats) uint64 { return s.Hits })
+	counter("cache_misses_total", "Number of cache misses.", func(s Stats) uint64 { return s.Misses })
+	counter("cache_sets_total", "Number of cache writes.", func(s Stats) uint64 { return s.Sets })
+	counter("cache_deletes_total", "Number of cache deletes.", func(s Stats) uint64 { return s.Deletes })
+
+	header(buf, "cache_evictions_total", "Number of removed entries by reason.", "counter")
+	for _, name := range names {
+		for _, reason := range []cache.EvictReason{cache.EvictReasonDeleted, cache.EvictReasonExpired, cache.EvictReasonCapacity} {
+			fmt.Fprintf(buf, "cache_evictions_total{cache=\"%s\",reason=\"%s\"} %d\n",
+				escape(name), reason, stats[name].Evictions[reason])
+		}
+	}
+
+	header(buf, "cache_loads_total", "Number of loads from the data source by result.", "counter")
+	for _, name := range names {
+		fmt.Fprintf(buf, "cache_loads_total{cache=\"%s\",result=\"success\"} %d\n", escape(name), stats[name].LoadSuccesses)
+		fmt.Fprintf(buf, "cache_loads_total{cache=\"%s\",result=\"failure\"} %d\n", escape(name), stats[name].LoadFailures)
+	}
+
+	header(buf, "cache_load_duration_seconds", "Latency of loads from the data source.", "histogram")
+	for _, name := range names {
+		h := stats[name].LoadLatency
+		var cumulative uint64
+		for i, bound := range h.Bounds {
+			cumulative += h.Counts[i]
+			fmt.Fprintf(buf, "cache_load_duration_seconds_bucket{cache=\"%s\",le=\"%s\"} %d\n",
+				escape(name), formatFloat(bound.Seconds()), cumulative)
+		}
+		fmt.Fprintf(buf, "cache_load_duration_seconds_bucket{cache=\"%s\",le=\"+Inf\"} %d\n", escape(name), h.Count)
+		fmt.Fprintf(buf, "cache_load_duration_seconds_sum{cache=\"%s\"} %s\n", escape(name), formatFloat(h.Sum.Seconds()))
+		fmt.Fprintf(buf, "cache_load_duration_seconds_count{cache=\"%s\"} %d\n", escape(name), h.Count)
+	}
+
+	header(buf, "cache_bytes", "Bytes used by cached values.", "gauge")
+	for _, name := range names {
+		fmt.Fprintf(buf, "cache_bytes{cache=\"%s\"} %d\n", escape(name), stats[name].Bytes)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func header(buf *bytes.Buffer, metric, help, typ string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", metric, help, metric, typ)
+}
+
+func escape(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"bytes"
+	"github.com/ac-zht/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	bounds := []time.Duration{100 * time.Millisecond, time.Second}
+	stats := map[string]Stats{
+		"local": {
+			Hits:      3,
+			Misses:    1,
+			Sets:      2,
+			Evictions: map[cache.EvictReason]uint64{cache.EvictReasonExpired: 4},
+			LoadLatency: Histogram{
+				Bounds: bounds,
+				Counts: []uint64{0, 0, 0},
+			},
+			Bytes: 128,
+		},
+		`db"1`: {
+			Deletes:       1,
+			LoadSuccesses: 2,
+			LoadFailures:  1,
+			LoadLatency: Histogram{
+				Bounds: bounds,
+				Counts: []uint64{1, 1, 1},
+				Count:  3,
+				Sum:    2500 * time.Millisecond,
+			},
+		},
+	}
+	buf := &bytes.Buffer{}
+	require.NoError(t, WritePrometheus(buf, stats))
+	assert.Equal(t, `# HELP cache_hits_total Number of cache hits.
+# TYPE cache_hits_total counter
+cache_hits_total{cache="db\"1"} 0
+cache_hits_total{cache="local"} 3
+# HELP cache_misses_total Number of cache misses.
+# TYPE cache_misses_total counter
+cache_misses_total{cache="db\"1"} 0
+cache_misses_total{cache="local"} 1
+# HELP cache_sets_total Number of cache writes.
+# TYPE cache_sets_total counter
+cache_sets_total{cache="db\"1"} 0
+cache_sets_total{cache="local"} 2
+# HELP cache_deletes_total Number of cache deletes.
+# TYPE cache_deletes_total counter
+cache_deletes_total{cache="db\"1"} 1
+cache_deletes_total{cache="local"} 0
+# HELP cache_evictions_total Number of removed entries by reason.
+# TYPE cache_evictions_total counter
+cache_evictions_total{cache="db\"1",reason="deleted"} 0
+cache_evictions_total{cache="db\"1",reason="expired"} 0
+cache_evictions_total{cache="db\"1",reason="capacity"} 0
+cache_evictions_total{cache="local",reason="deleted"} 0
+cache_evictions_total{cache="local",reason="expired"} 4
+cache_evictions_total{cache="local",reason="capacity"} 0
+# HELP cache_loads_total Number of loads from the data source by result.
+# TYPE cache_loads_total counter
+cache_loads_total{cache="db\"1",result="success"} 2
+cache_loads_total{cache="db\"1",result="failure"} 1
+cache_loads_total{cache="local",result="success"} 0
+cache_loads_total{cache="local",result="failure"} 0
+# HELP cache_load_duration_seconds Latency of loads from the data source.
+# TYPE cache_load_duration_seconds histogram
+cache_load_duration_seconds_bucket{cache="db\"1",le="0.1"} 1
+cache_load_duration_seconds_bucket{cache="db\"1",le="1"} 2
+cache_load_duration_seconds_bucket{cache="db\"1",le="+Inf"} 3
+cache_load_duration_seconds_sum{cache="db\"1"} 2.5
+cache_load_duration_seconds_count{cache="db\"1"} 3
+cache_load_duration_seconds_bucket{cache="local",le="0.1"} 0
+cache_load_duration_seconds_bucket{cache="local",le="1"} 0
+cache_load_duration_seconds_bucket{cache="local",le="+Inf"} 0
+cache_load_duration_seconds_sum{cache="local"} 0
+cache_load_duration_seconds_count{cache="local"} 0
+# HELP cache_bytes Bytes used by cached values.
+# TYPE cache_bytes gauge
+cache_bytes{cache="db\"1"} 0
+cache_bytes{cache="local"} 128
+`, buf.String())
+}
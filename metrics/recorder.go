@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"github.com/ac-zht/cache"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// evictReasonCnt cache.EvictReason 的取值个数
+const evictReasonCnt = int(cache.EvictReasonCapacity) + 1
+
+type RecorderOption func(r *Recorder)
+
+// Recorder 并发安全的统计计数器，各缓存实现内嵌 Recorder 并通过 Snapshot 导出 Stats
+type Recorder struct {
+	hits          uint64
+	misses        uint64
+	sets          uint64
+	deletes       uint64
+	loadSuccesses uint64
+	loadFailures  uint64
+	latencySum    uint64
+	bytes         int64
+	evictions     [evictReasonCnt]uint64
+
+	bounds []time.Duration
+	counts []uint64
+}
+
+func NewRecorder(opts ...RecorderOption) *Recorder {
+	res := &Recorder{
+		bounds: DefaultBuckets,
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+	res.counts = make([]uint64, len(res.bounds)+1)
+	return res
+}
+
+// RecorderWithBuckets 替换加载耗时直方图的桶上界，bounds 需要升序
+func RecorderWithBuckets(bounds []time.Duration) RecorderOption {
+	return func(r *Recorder) {
+		r.bounds = bounds
+	}
+}
+
+func (r *Recorder) Hit() {
+	atomic.AddUint64(&r.hits, 1)
+}
+
+func (r *Recorder) Miss() {
+	atomic.AddUint64(&r.misses, 1)
+}
+
+func (r *Recorder) Set() {
+	atomic.AddUint64(&r.sets, 1)
+}
+
+func (r *Recorder) Delete() {
+	atomic.AddUint64(&r.deletes, 1)
+}
+
+func (r *Recorder) Evict(reason cache.EvictReason) {
+	if int(reason) < 0 || int(reason) >= evictReasonCnt {
+		return
+	}
+	atomic.AddUint64(&r.evictions[reason], 1)
+}
+
+// Load 记录一次加载的耗时和结果
+func (r *Recorder) Load(latency time.Duration, err error) {
+	if err == nil {
+		atomic.AddUint64(&r.loadSuccesses, 1)
+	} else {
+		atomic.AddUint64(&r.loadFailures, 1)
+	}
+	i := sort.Search(len(r.bounds), func(i int) bool {
+		return latency <= r.bounds[i]
+	})
+	atomic.AddUint64(&r.counts[i], 1)
+	atomic.AddUint64(&r.latencySum, uint64(latency))
+}
+
+// AddBytes 调整占用字节数，n 可以为负数
+func (r *Recorder) AddBytes(n int64) {
+	atomic.AddInt64(&r.bytes, n)
+}
+
+// Snapshot 各计数分别原子读取，并发写入时快照内的指标之间可能略有偏差
+func (r *Recorder) Snapshot() Stats {
+	res := Stats{
+		Hits:          atomic.LoadUint64(&r.hits),
+		Misses:        atomic.LoadUint64(&r.misses),
+		Sets:          atomic.LoadUint64(&r.sets),
+		Deletes:       atomic.LoadUint64(&r.deletes),
+		Evictions:     make(map[cache.EvictReason]uint64, evictReasonCnt),
+		LoadSuccesses: atomic.LoadUint64(&r.loadSuccesses),
+		LoadFailures:  atomic.LoadUint64(&r.loadFailures),
+		LoadLatency: Histogram{
+			Bounds: r.bounds,
+			Counts: make([]uint64, len(r.counts)),
+			Sum:    time.Duration(atomic.LoadUint64(&r.latencySum)),
+		},
+		Bytes: atomic.LoadInt64(&r.bytes),
+	}
+	for i := range r.evictions {
+		if cnt := atomic.LoadUint64(&r.evictions[i]); cnt > 0 {
+			res.Evictions[cache.EvictReason(i)] = cnt
+		}
+	}
+	for i := range r.counts {
+		res.LoadLatency.Counts[i] = atomic.LoadUint64(&r.counts[i])
+		res.LoadLatency.Count += res.LoadLatency.Counts[i]
+	}
+	return res
+}
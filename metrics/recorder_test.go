@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"errors"
+	"github.com/ac-zht/cache"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecorder_Snapshot(t *testing.T) {
+	r := NewRecorder(RecorderWithBuckets([]time.Duration{time.Millisecond, 10 * time.Millisecond}))
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Hit()
+			r.Hit()
+			r.Miss()
+			r.Set()
+			r.Delete()
+			r.Evict(cache.EvictReasonExpired)
+			r.AddBytes(10)
+		}()
+	}
+	wg.Wait()
+	r.Evict(cache.EvictReasonCapacity)
+	r.Evict(cache.EvictReason(100))
+	r.AddBytes(-30)
+	r.Load(time.Millisecond, nil)
+	r.Load(5*time.Millisecond, nil)
+	r.Load(time.Second, errors.New("db down"))
+
+	assert.Equal(t, Stats{
+		Hits:    20,
+		Misses:  10,
+		Sets:    10,
+		Deletes: 10,
+		Evictions: map[cache.EvictReason]uint64{
+			cache.EvictReasonExpired:  10,
+			cache.EvictReasonCapacity: 1,
+		},
+		LoadSuccesses: 2,
+		LoadFailures:  1,
+		LoadLatency: Histogram{
+			Bounds: []time.Duration{time.Millisecond, 10 * time.Millisecond},
+			Counts: []uint64{1, 1, 1},
+			Count:  3,
+			Sum:    1006 * time.Millisecond,
+		},
+		Bytes: 70,
+	}, r.Snapshot())
+}
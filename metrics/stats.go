@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"github.com/ac-zht/cache"
+	"time"
+)
+
+// DefaultBuckets 加载耗时直方图默认的桶上界
+var DefaultBuckets = []time.Duration{
+	time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond,
+	50 * time.Millisecond, 100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+}
+
+// Stats 某一时刻的统计快照，不支持的指标保持零值
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Sets      uint64
+	Deletes   uint64
+	Evictions map[cache.EvictReason]uint64
+
+	LoadSuccesses uint64
+	LoadFailures  uint64
+	LoadLatency   Histogram
+
+	// Bytes value 占用的字节数
+	Bytes int64
+}
+
+// Histogram Counts[i] 为耗时不超过 Bounds[i] 且超过 Bounds[i-1] 的次数，
+// 最后一个元素为超过所有上界的次数，len(Counts) == len(Bounds)+1
+type Histogram struct {
+	Bounds []time.Duration
+	Counts []uint64
+	Count  uint64
+	Sum    time.Duration
+}
+
+// HitRatio 命中率，没有读取时为 0
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// Merge 合并两个快照，用于汇总分片等多个实例，直方图的桶上界必须相同
+func (s Stats) Merge(o Stats) Stats {
+	res := Stats{
+		Hits:          s.Hits + o.Hits,
+		Misses:        s.Misses + o.Misses,
+		Sets:          s.Sets + o.Sets,
+		Deletes:       s.Deletes + o.Deletes,
+		Evictions:     make(map[cache.EvictReason]uint64, len(s.Evictions)),
+		LoadSuccesses: s.LoadSuccesses + o.LoadSuccesses,
+		LoadFailures:  s.LoadFailures + o.LoadFailures,
+		LoadLatency:   s.LoadLatency.merge(o.LoadLatency),
+		Bytes:         s.Bytes + o.Bytes,
+	}
+	for reason, cnt := range s.Evictions {
+		res.Evictions[reason] += cnt
+	}
+	for reason, cnt := range o.Evictions {
+		res.Evictions[reason] += cnt
+	}
+	return res
+}
+
+func (h Histogram) merge(o Histogram) Histogram {
+	if len(h.Counts) == 0 {
+		return o
+	}
+	if len(o.Counts) == 0 {
+		return h
+	}
+	res := Histogram{
+		Bounds: h.Bounds,
+		Counts: make([]uint64, len(h.Counts)),
+		Count:  h.Count + o.Count,
+		Sum:    h.Sum + o.Sum,
+	}
+	for i := range res.Counts {
+		res.Counts[i] = h.Counts[i] + o.Counts[i]
+	}
+	return res
+}
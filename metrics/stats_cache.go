@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/ac-zht/cache"
+	"time"
+)
+
+var _ cache.Cache = &StatsCache{}
+
+// evictedHooker 能追加淘汰回调且不覆盖已注册回调的缓存实现该接口，例如 local_cache.BytesCache
+type evictedHooker interface {
+	AddEvictedHook(fn func(key string, val []byte, reason cache.EvictReason))
+}
+
+// StatsCache 为任意 cache.Cache 统计命中、写入和删除，
+// 底层缓存提供 AddEvictedHook 时按原因统计淘汰，否则不统计淘汰
+type StatsCache struct {
+	cache.Cache
+	stats *Recorder
+}
+
+func NewStatsCache(c cache.Cache, opts ...RecorderOption) *StatsCache {
+	res := &StatsCache{
+		Cache: c,
+		stats: NewRecorder(opts...),
+	}
+	if h, ok := c.(evictedHooker); ok {
+		h.AddEvictedHook(func(key string, val []byte, reason cache.EvictReason) {
+			res.stats.Evict(reason)
+		})
+	}
+	return res
+}
+
+func (c *StatsCache) Get(ctx context.Context, key string) ([]byte, error) {
+	val, err := c.Cache.Get(ctx, key)
+	switch {
+	case err == nil:
+		c.stats.Hit()
+	case errors.Is(err, cache.ErrKeyNotFound):
+		c.stats.Miss()
+	}
+	return val, err
+}
+
+func (c *StatsCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
+	err := c.Cache.Set(ctx, key, val, expiration)
+	if err == nil {
+		c.stats.Set()
+	}
+	return err
+}
+
+func (c *StatsCache) Delete(ctx context.Context, key string) error {
+	err := c.Cache.Delete(ctx, key)
+	if err == nil {
+		c.stats.Delete()
+	}
+	return err
+}
+
+func (c *StatsCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	val, err := c.Cache.LoadAndDelete(ctx, key)
+	if err == nil {
+		c.stats.Delete()
+	}
+	return val, err
+}
+
+func (c *StatsCache) Stats() Stats {
+	return c.stats.Snapshot()
+}
+
+// Close 底层缓存实现了 cache.Closer 时关闭
+func (c *StatsCache) Close(ctx context.Context) error {
+	if closer, ok := c.Cache.(cache.Closer); ok {
+		return closer.Close(ctx)
+	}
+	return nil
+}
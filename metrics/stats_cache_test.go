@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ac-zht/cache"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestStatsCache(t *testing.T) {
+	m := &mockCache{data: map[string][]byte{}}
+	c := NewStatsCache(m)
+	ctx := context.Background()
+
+	assert.NoError(t, c.Set(ctx, "k1", []byte("v1"), time.Minute))
+	assert.NoError(t, c.Set(ctx, "k2", []byte("v2"), time.Minute))
+	_, err := c.Get(ctx, "k1")
+	assert.NoError(t, err)
+	_, err = c.Get(ctx, "k3")
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+	m.getErr = errors.New("network")
+	_, err = c.Get(ctx, "k1")
+	assert.Error(t, err)
+	assert.NoError(t, c.Delete(ctx, "k1"))
+	_, err = c.LoadAndDelete(ctx, "k2")
+	assert.NoError(t, err)
+	_, err = c.LoadAndDelete(ctx, "k2")
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+	m.evict("k4", cache.EvictReasonExpired)
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(2), stats.Sets)
+	assert.Equal(t, uint64(2), stats.Deletes)
+	assert.Equal(t, map[cache.EvictReason]uint64{
+		cache.EvictReasonDeleted: 2,
+		cache.EvictReasonExpired: 1,
+	}, stats.Evictions)
+}
+
+func TestStatsCache_KeepEvictedCallback(t *testing.T) {
+	m := &mockCache{data: map[string][]byte{"k1": []byte("v1"), "k2": []byte("v2")}}
+	var evicted []string
+	m.OnEvictedWithReason(func(key string, val []byte, reason cache.EvictReason) {
+		evicted = append(evicted, key)
+	})
+	c := NewStatsCache(m)
+	m.evict("k1", cache.EvictReasonExpired)
+	//包装之后注册的回调也不影响统计
+	m.OnEvictedWithReason(func(key string, val []byte, reason cache.EvictReason) {})
+	m.evict("k2", cache.EvictReasonExpired)
+
+	assert.Equal(t, []string{"k1"}, evicted)
+	assert.Equal(t, map[cache.EvictReason]uint64{
+		cache.EvictReasonExpired: 2,
+	}, c.Stats().Evictions)
+}
+
+type mockCache struct {
+	data     map[string][]byte
+	getErr   error
+	hooks    []func(key string, val []byte, reason cache.EvictReason)
+	onReason func(key string, val []byte, reason cache.EvictReason)
+}
+
+func (m *mockCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	val, ok := m.data[key]
+	if !ok {
+		return nil, fmt.Errorf("%w, key: %s", cache.ErrKeyNotFound, key)
+	}
+	return val, nil
+}
+
+func (m *mockCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
+	m.data[key] = val
+	return nil
+}
+
+func (m *mockCache) Delete(ctx context.Context, key string) error {
+	_, err := m.LoadAndDelete(ctx, key)
+	if errors.Is(err, cache.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (m *mockCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	val, ok := m.data[key]
+	if !ok {
+		return nil, cache.ErrKeyNotFound
+	}
+	m.evict(key, cache.EvictReasonDeleted)
+	return val, nil
+}
+
+func (m *mockCache) OnEvicted(fn func(key string, val []byte)) {}
+
+func (m *mockCache) OnEvictedWithReason(fn func(key string, val []byte, reason cache.EvictReason)) {
+	m.onReason = fn
+}
+
+func (m *mockCache) AddEvictedHook(fn func(key string, val []byte, reason cache.EvictReason)) {
+	m.hooks = append(m.hooks, fn)
+}
+
+func (m *mockCache) evict(key string, reason cache.EvictReason) {
+	val := m.data[key]
+	delete(m.data, key)
+	for _, hook := range m.hooks {
+		hook(key, val, reason)
+	}
+	if m.onReason != nil {
+		m.onReason(key, val, reason)
+	}
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"github.com/ac-zht/cache"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestStats_HitRatio(t *testing.T) {
+	assert.Equal(t, float64(0), Stats{}.HitRatio())
+	assert.Equal(t, 0.75, Stats{Hits: 3, Misses: 1}.HitRatio())
+}
+
+func TestStats_Merge(t *testing.T) {
+	bounds := []time.Duration{time.Millisecond}
+	s1 := Stats{
+		Hits:        1,
+		Evictions:   map[cache.EvictReason]uint64{cache.EvictReasonDeleted: 1},
+		LoadLatency: Histogram{Bounds: bounds, Counts: []uint64{1, 0}, Count: 1, Sum: time.Millisecond},
+		Bytes:       10,
+	}
+	s2 := Stats{
+		Hits:        2,
+		Misses:      3,
+		Evictions:   map[cache.EvictReason]uint64{cache.EvictReasonDeleted: 2, cache.EvictReasonExpired: 1},
+		LoadLatency: Histogram{Bounds: bounds, Counts: []uint64{0, 2}, Count: 2, Sum: time.Second},
+		Bytes:       5,
+	}
+	assert.Equal(t, Stats{
+		Hits:        3,
+		Misses:      3,
+		Evictions:   map[cache.EvictReason]uint64{cache.EvictReasonDeleted: 3, cache.EvictReasonExpired: 1},
+		LoadLatency: Histogram{Bounds: bounds, Counts: []uint64{1, 2}, Count: 3, Sum: time.Second + time.Millisecond},
+		Bytes:       15,
+	}, s1.Merge(s2))
+
+	//零值与任意快照合并得到原快照
+	assert.Equal(t, s1.LoadLatency, Stats{}.Merge(s1).LoadLatency)
+}
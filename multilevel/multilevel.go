@@ -0,0 +1,153 @@
+package multilevel
+
+import (
+	"context"
+	"errors"
+	"github.com/ac-zht/cache"
+	"sync/atomic"
+	"time"
+)
+
+var _ cache.Cache = &MultiLevelCache{}
+
+// Tier 一级缓存，Expiration 为写入和从下层回填到该层时使用的过期时间，
+// 不大于 0 时 Set 沿用传入的过期时间，回填时不设置过期时间（永不过期）
+type Tier struct {
+	Cache      cache.Cache
+	Expiration time.Duration
+}
+
+// TierStats 单层缓存的命中统计
+type TierStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// MultiLevelCache 由上到下依次查询多层缓存，下层命中后回填所有上层，
+// 最后一层可以是 read_through.ReadThroughCache，由它负责从数据源加载
+type MultiLevelCache struct {
+	tiers []Tier
+	stats []*TierStats
+}
+
+func NewMultiLevelCache(tiers ...Tier) *MultiLevelCache {
+	stats := make([]*TierStats, len(tiers))
+	for i := range stats {
+		stats[i] = &TierStats{}
+	}
+	return &MultiLevelCache{
+		tiers: tiers,
+		stats: stats,
+	}
+}
+
+// Get 某层返回非 cache.ErrKeyNotFound 的错误时继续查询下一层，所有层都未命中时返回第一个这样的错误
+func (m *MultiLevelCache) Get(ctx context.Context, key string) ([]byte, error) {
+	var firstErr error
+	for i, tier := range m.tiers {
+		val, err := tier.Cache.Get(ctx, key)
+		if err == nil {
+			atomic.AddUint64(&m.stats[i].Hits, 1)
+			m.backfill(ctx, i, key, val)
+			return val, nil
+		}
+		atomic.AddUint64(&m.stats[i].Misses, 1)
+		if firstErr == nil && !errors.Is(err, cache.ErrKeyNotFound) {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return nil, cache.ErrKeyNotFound
+}
+
+// backfill 回填失败不影响本次读取
+func (m *MultiLevelCache) backfill(ctx context.Context, hit int, key string, val []byte) {
+	for i := hit - 1; i >= 0; i-- {
+		_ = m.tiers[i].Cache.Set(ctx, key, val, m.tiers[i].Expiration)
+	}
+}
+
+// Set 从最下层开始写入，保证上层有的数据下层一定有
+func (m *MultiLevelCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
+	for i := len(m.tiers) - 1; i >= 0; i-- {
+		exp := m.tiers[i].Expiration
+		if exp <= 0 {
+			exp = expiration
+		}
+		if err := m.tiers[i].Cache.Set(ctx, key, val, exp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete 从最下层开始删除，避免上层删除后又被下层回填，返回第一个错误
+func (m *MultiLevelCache) Delete(ctx context.Context, key string) error {
+	var res error
+	for i := len(m.tiers) - 1; i >= 0; i-- {
+		if err := m.tiers[i].Cache.Delete(ctx, key); err != nil && res == nil {
+			res = err
+		}
+	}
+	return res
+}
+
+// LoadAndDelete 删除所有层中的 key，返回最上层的值
+func (m *MultiLevelCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	var (
+		res      []byte
+		found    bool
+		firstErr error
+	)
+	for i := len(m.tiers) - 1; i >= 0; i-- {
+		val, err := m.tiers[i].Cache.LoadAndDelete(ctx, key)
+		if err == nil {
+			res, found = val, true
+			continue
+		}
+		if firstErr == nil && !errors.Is(err, cache.ErrKeyNotFound) {
+			firstErr = err
+		}
+	}
+	if found {
+		return res, nil
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return nil, cache.ErrKeyNotFound
+}
+
+// OnEvicted 注册到最上层，下层的淘汰不会触发回调
+func (m *MultiLevelCache) OnEvicted(fn func(key string, val []byte)) {
+	if len(m.tiers) > 0 {
+		m.tiers[0].Cache.OnEvicted(fn)
+	}
+}
+
+// Stats 按层返回命中统计
+func (m *MultiLevelCache) Stats() []TierStats {
+	res := make([]TierStats, len(m.stats))
+	for i, s := range m.stats {
+		res[i] = TierStats{
+			Hits:   atomic.LoadUint64(&s.Hits),
+			Misses: atomic.LoadUint64(&s.Misses),
+		}
+	}
+	return res
+}
+
+// Close 关闭所有实现了 cache.Closer 的层，返回第一个错误
+func (m *MultiLevelCache) Close(ctx context.Context) error {
+	var res error
+	for _, tier := range m.tiers {
+		if closer, ok := tier.Cache.(cache.Closer); ok {
+			if err := closer.Close(ctx); err != nil && res == nil {
+				res = err
+			}
+		}
+	}
+	return res
+}
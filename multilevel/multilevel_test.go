@@ -0,0 +1,142 @@
+package multilevel
+
+import (
+	"context"
+	"errors"
+	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/local_cache"
+	"github.com/ac-zht/cache/read_through"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMultiLevelCache_Get(t *testing.T) {
+	testCase := []struct {
+		name      string
+		before    func(l1, l2 *local_cache.BytesCache)
+		loadFunc  func(ctx context.Context, key string) ([]byte, error)
+		key       string
+		wantVal   []byte
+		wantStats []TierStats
+		wantL1    bool
+		wantL2    bool
+		wantError error
+	}{
+		{
+			name: "l1 hit",
+			before: func(l1, l2 *local_cache.BytesCache) {
+				_ = l1.Set(context.Background(), "key", []byte("value"), time.Minute)
+			},
+			key:       "key",
+			wantVal:   []byte("value"),
+			wantStats: []TierStats{{Hits: 1}, {}, {}},
+			wantL1:    true,
+		},
+		{
+			name: "l2 hit and backfill l1",
+			before: func(l1, l2 *local_cache.BytesCache) {
+				_ = l2.Set(context.Background(), "key", []byte("value"), time.Minute)
+			},
+			key:       "key",
+			wantVal:   []byte("value"),
+			wantStats: []TierStats{{Misses: 1}, {Hits: 1}, {}},
+			wantL1:    true,
+			wantL2:    true,
+		},
+		{
+			name:   "load by read through and backfill",
+			before: func(l1, l2 *local_cache.BytesCache) {},
+			loadFunc: func(ctx context.Context, key string) ([]byte, error) {
+				return []byte("value"), nil
+			},
+			key:       "key",
+			wantVal:   []byte("value"),
+			wantStats: []TierStats{{Misses: 1}, {Misses: 1}, {Hits: 1}},
+			wantL1:    true,
+			wantL2:    true,
+		},
+		{
+			name:   "load fail",
+			before: func(l1, l2 *local_cache.BytesCache) {},
+			loadFunc: func(ctx context.Context, key string) ([]byte, error) {
+				return nil, errors.New("db down")
+			},
+			key:       "key",
+			wantStats: []TierStats{{Misses: 1}, {Misses: 1}, {Misses: 1}},
+			wantError: errors.New("db down"),
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			l1, l2 := local_cache.NewBytesCache(10), local_cache.NewBytesCache(10)
+			tc.before(l1, l2)
+			loadFunc := tc.loadFunc
+			if loadFunc == nil {
+				loadFunc = func(ctx context.Context, key string) ([]byte, error) {
+					return nil, cache.ErrKeyNotFound
+				}
+			}
+			m := NewMultiLevelCache(
+				Tier{Cache: l1, Expiration: time.Second},
+				Tier{Cache: l2, Expiration: time.Minute},
+				Tier{Cache: read_through.NewReadThroughCache(local_cache.NewBytesCache(10), time.Hour, loadFunc)},
+			)
+			val, err := m.Get(context.Background(), tc.key)
+			assert.Equal(t, tc.wantError, err)
+			assert.Equal(t, tc.wantStats, m.Stats())
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantVal, val)
+			_, err = l1.Get(context.Background(), tc.key)
+			assert.Equal(t, tc.wantL1, err == nil)
+			_, err = l2.Get(context.Background(), tc.key)
+			assert.Equal(t, tc.wantL2, err == nil)
+		})
+	}
+}
+
+func TestMultiLevelCache_BackfillWithoutExpiration(t *testing.T) {
+	l1, l2 := local_cache.NewBytesCache(10), local_cache.NewBytesCache(10)
+	m := NewMultiLevelCache(Tier{Cache: l1}, Tier{Cache: l2, Expiration: time.Minute})
+	_ = l2.Set(context.Background(), "key", []byte("value"), time.Minute)
+	val, err := m.Get(context.Background(), "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), val)
+	//l1 没有设置 Expiration，回填的数据永不过期
+	val, err = l1.Get(context.Background(), "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), val)
+	assert.True(t, l1.Data["key"].Deadline().IsZero())
+
+	require.NoError(t, m.Set(context.Background(), "k2", []byte("v2"), time.Minute))
+	assert.False(t, l1.Data["k2"].Deadline().IsZero())
+}
+
+func TestMultiLevelCache_Delete(t *testing.T) {
+	l1, l2 := local_cache.NewBytesCache(10), local_cache.NewBytesCache(10)
+	m := NewMultiLevelCache(Tier{Cache: l1, Expiration: time.Second}, Tier{Cache: l2})
+	evicted := map[string][]byte{}
+	m.OnEvicted(func(key string, val []byte) {
+		evicted[key] = val
+	})
+	require.NoError(t, m.Set(context.Background(), "k1", []byte("v1"), time.Minute))
+	require.NoError(t, m.Set(context.Background(), "k2", []byte("v2"), time.Minute))
+	assert.Equal(t, []byte("v1"), l2.Data["k1"].Val())
+
+	require.NoError(t, m.Delete(context.Background(), "k1"))
+	val, err := m.LoadAndDelete(context.Background(), "k2")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v2"), val)
+	_, err = m.LoadAndDelete(context.Background(), "k2")
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+	assert.Empty(t, l1.Data)
+	assert.Empty(t, l2.Data)
+	assert.Equal(t, map[string][]byte{"k1": []byte("v1"), "k2": []byte("v2")}, evicted)
+
+	require.NoError(t, m.Close(context.Background()))
+	_, err = l2.Get(context.Background(), "k1")
+	assert.Equal(t, cache.ErrClosed, err)
+}
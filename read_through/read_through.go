@@ -1,34 +1,195 @@
 package read_through
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/bloom"
+	"github.com/ac-zht/cache/clock"
+	"github.com/ac-zht/cache/internal/async"
+	"github.com/ac-zht/cache/metrics"
+	"github.com/ac-zht/cache/worker_pool"
 	"log"
 	"time"
 )
 
+var _ cache.BatchCache = &ReadThroughCache{}
+
+// ErrNegativeCached 缓存中记录了该 key 在数据源中不存在，errors.Is(err, cache.ErrKeyNotFound) 同样成立
+var ErrNegativeCached = fmt.Errorf("%w, negative cached", cache.ErrKeyNotFound)
+
+// negativeValue 不存在结果在底层缓存中的占位值，与之相同的值会被当作不存在
+var negativeValue = []byte("\x00cache:negative\x00")
+
+type ReadThroughCacheOption func(cache *ReadThroughCache)
+
 type ReadThroughCache struct {
 	cache.Cache
 	expiration time.Duration
 	LoadFunc   func(ctx context.Context, key string) ([]byte, error)
+
+	//batchLoadFunc 批量加载，返回结果中没有的 key 视为不存在
+	batchLoadFunc func(ctx context.Context, keys []string) (map[string][]byte, error)
+
+	//errHandler 处理异步刷新最终失败的错误，maxRetry 为失败后的重试次数，每次重试间隔翻倍
+	errHandler func(ctx context.Context, key string, err error)
+	maxRetry   int
+	backoff    time.Duration
+	//pool 不为空时异步刷新提交到 pool 执行，否则每次启动一个 goroutine
+	pool *worker_pool.Group
+	//asyncTimeout 异步刷新的超时时间，异步刷新使用脱离调用方取消的 context
+	asyncTimeout time.Duration
+	clock        clock.Clock
+	//grace 保存加载过的值，maxStale 为缓存过期后仍可作为旧值返回的时长
+	grace    cache.Cache
+	maxStale time.Duration
+	//negativeExpiration 大于 0 时缓存数据源中不存在的结果，isNotFound 判断加载错误是否表示不存在
+	negativeExpiration time.Duration
+	isNotFound         func(err error) bool
+	//filter 不为空时跳过一定不存在的 key，加载成功和写入的 key 添加到 filter
+	filter bloom.Filter
+	//loadWithTTLFunc 不为空时代替 LoadFunc，返回的过期时间大于 0 时优先使用，
+	//否则 ttlFunc 不为空时按 key 和 value 计算过期时间，最后按 jitter 比例随机浮动
+	loadWithTTLFunc func(ctx context.Context, key string) ([]byte, time.Duration, error)
+	ttlFunc         func(key string, val []byte) time.Duration
+	jitter          float64
+
+	//tracker 记录尚未完成的异步刷新和关闭状态，Close 时等待其完成
+	tracker *async.Tracker
+	stats   *metrics.Recorder
 }
 
 func NewReadThroughCache(cache cache.Cache, expiration time.Duration,
-	LoadFunc func(ctx context.Context, key string) ([]byte, error)) *ReadThroughCache {
+	LoadFunc func(ctx context.Context, key string) ([]byte, error), opts ...ReadThroughCacheOption) *ReadThroughCache {
 	res := &ReadThroughCache{
-		Cache:      cache,
-		expiration: expiration,
-		LoadFunc:   LoadFunc,
+		Cache:        cache,
+		expiration:   expiration,
+		LoadFunc:     LoadFunc,
+		tracker:      async.NewTracker(),
+		stats:        metrics.NewRecorder(),
+		asyncTimeout: time.Second * 10,
+		clock:        clock.New(),
+		isNotFound:   isKeyNotFound,
+		errHandler: func(ctx context.Context, key string, err error) {
+			log.Printf("cache: async refresh fail, key: %s, err: %v", key, err)
+		},
+	}
+	for _, opt := range opts {
+		opt(res)
 	}
 	return res
 }
 
+// ReadThroughCacheWithBatchLoadFunc GetMulti 使用 fn 一次加载所有未命中的 key，未设置时逐个调用 LoadFunc
+func ReadThroughCacheWithBatchLoadFunc(fn func(ctx context.Context, keys []string) (map[string][]byte, error)) ReadThroughCacheOption {
+	return func(cache *ReadThroughCache) {
+		cache.batchLoadFunc = fn
+	}
+}
+
+// ReadThroughCacheWithClock 替换判断软过期等使用的时钟
+func ReadThroughCacheWithClock(clock clock.Clock) ReadThroughCacheOption {
+	return func(cache *ReadThroughCache) {
+		cache.clock = clock
+	}
+}
+
+// ReadThroughCacheWithErrorHandler 异步刷新重试后仍失败时调用 fn，默认只打印日志
+func ReadThroughCacheWithErrorHandler(fn func(ctx context.Context, key string, err error)) ReadThroughCacheOption {
+	return func(cache *ReadThroughCache) {
+		cache.errHandler = fn
+	}
+}
+
+// ReadThroughCacheWithPool 异步刷新交给 pool 执行，同一个 key 排队中的任务只执行最后一次提交的，
+// 多个缓存共享同一个 pool 时各自的任务互不合并；提交失败的错误交给 errHandler
+func ReadThroughCacheWithPool(pool *worker_pool.Pool) ReadThroughCacheOption {
+	return func(cache *ReadThroughCache) {
+		cache.pool = pool.NewGroup()
+	}
+}
+
+// ReadThroughCacheWithAsyncTimeout 异步刷新的超时时间，默认 10 秒，为 0 时不超时。
+// 异步刷新使用保留调用方 ctx 中的值但不随其取消的 context，超时从任务开始执行时计算
+func ReadThroughCacheWithAsyncTimeout(timeout time.Duration) ReadThroughCacheOption {
+	return func(cache *ReadThroughCache) {
+		cache.asyncTimeout = timeout
+	}
+}
+
+// ReadThroughCacheWithStaleOnError LoadFunc 或 Set 成功时把值写入 grace，过期时间为 expiration+maxStale；
+// LoadFunc 失败（数据源中不存在除外）时返回 grace 中的旧值和同时包装了 cache.ErrServedStale 与加载错误的错误，旧值不会写回缓存。
+// grace 不能与底层缓存是同一个实例，通常使用一个本地缓存
+func ReadThroughCacheWithStaleOnError(grace cache.Cache, maxStale time.Duration) ReadThroughCacheOption {
+	return func(cache *ReadThroughCache) {
+		cache.grace = grace
+		cache.maxStale = maxStale
+	}
+}
+
+// ReadThroughCacheWithNegativeCache LoadFunc 返回的错误满足 isNotFound 时在底层缓存中写入占位值，过期时间为 expiration，
+// 期间读取该 key 返回包装了 ErrNegativeCached 的错误而不再调用 LoadFunc。isNotFound 为空时使用 errors.Is(err, cache.ErrKeyNotFound)
+func ReadThroughCacheWithNegativeCache(expiration time.Duration, isNotFound func(err error) bool) ReadThroughCacheOption {
+	return func(cache *ReadThroughCache) {
+		cache.negativeExpiration = expiration
+		if isNotFound != nil {
+			cache.isNotFound = isNotFound
+		}
+	}
+}
+
+// ReadThroughCacheWithBloomFilter filter 判断一定不存在的 key 直接返回 cache.ErrKeyNotFound，不调用 LoadFunc，
+// filter 需要预先通过 bloom.Populate 加入数据源中已有的 key，之后加载成功以及通过 Set、SetMulti 写入的 key 会自动加入，
+// 绕过本缓存写入数据源的 key 需要调用 AddToFilter
+func ReadThroughCacheWithBloomFilter(filter bloom.Filter) ReadThroughCacheOption {
+	return func(cache *ReadThroughCache) {
+		cache.filter = filter
+	}
+}
+
+// ReadThroughCacheWithTTLJitter 写入缓存的过期时间在 [ttl*(1-percent), ttl*(1+percent)] 内随机浮动，
+// 避免同时加载的 key 同时过期，percent 取值 (0, 1)
+func ReadThroughCacheWithTTLJitter(percent float64) ReadThroughCacheOption {
+	return func(cache *ReadThroughCache) {
+		cache.jitter = percent
+	}
+}
+
+// ReadThroughCacheWithTTLFunc 按 key 和加载结果计算过期时间，代替构造时传入的 expiration
+func ReadThroughCacheWithTTLFunc(fn func(key string, val []byte) time.Duration) ReadThroughCacheOption {
+	return func(cache *ReadThroughCache) {
+		cache.ttlFunc = fn
+	}
+}
+
+// ReadThroughCacheWithLoadWithTTLFunc 代替 LoadFunc 加载数据，返回的过期时间大于 0 时作为该 key 的过期时间，
+// 设置后构造时传入的 LoadFunc 可以为 nil
+func ReadThroughCacheWithLoadWithTTLFunc(fn func(ctx context.Context, key string) ([]byte, time.Duration, error)) ReadThroughCacheOption {
+	return func(cache *ReadThroughCache) {
+		cache.loadWithTTLFunc = fn
+	}
+}
+
+// ReadThroughCacheWithRetry 异步刷新失败后最多重试 maxRetry 次，首次重试前等待 backoff，之后每次翻倍
+func ReadThroughCacheWithRetry(maxRetry int, backoff time.Duration) ReadThroughCacheOption {
+	return func(cache *ReadThroughCache) {
+		cache.maxRetry = maxRetry
+		cache.backoff = backoff
+	}
+}
+
 // Get 同步操作
 func (c *ReadThroughCache) Get(ctx context.Context, key string) ([]byte, error) {
-	val, err := c.Cache.Get(ctx, key)
-	if err == cache.ErrKeyNotFound {
-		if val, err = c.LoadFunc(ctx, key); err == nil {
-			err2 := c.Cache.Set(ctx, key, val, c.expiration)
+	if c.isClosed() {
+		return nil, cache.ErrClosed
+	}
+	val, err := c.get(ctx, key)
+	if miss(err) {
+		var ttl time.Duration
+		if val, ttl, err = c.load(ctx, key); err == nil {
+			err2 := c.Cache.Set(ctx, key, val, c.jitterTTL(ttl))
 			if err2 != nil {
 				return val, cache.NewErrRefreshCacheFail(key)
 			}
@@ -39,15 +200,17 @@ func (c *ReadThroughCache) Get(ctx context.Context, key string) ([]byte, error)
 
 // SemiAsyncGet 半异步操作
 func (c *ReadThroughCache) SemiAsyncGet(ctx context.Context, key string) ([]byte, error) {
-	val, err := c.Cache.Get(ctx, key)
-	if err == cache.ErrKeyNotFound {
-		if val, err = c.LoadFunc(ctx, key); err == nil {
-			go func() {
-				err2 := c.Cache.Set(ctx, key, val, c.expiration)
-				if err2 != nil {
-					log.Fatalln(err2)
-				}
-			}()
+	if c.isClosed() {
+		return nil, cache.ErrClosed
+	}
+	val, err := c.get(ctx, key)
+	if miss(err) {
+		var ttl time.Duration
+		if val, ttl, err = c.load(ctx, key); err == nil {
+			data, ttl := val, c.jitterTTL(ttl)
+			c.async(ctx, key, func(ctx context.Context) error {
+				return c.Cache.Set(ctx, key, data, ttl)
+			})
 		}
 	}
 	return val, err
@@ -55,16 +218,439 @@ func (c *ReadThroughCache) SemiAsyncGet(ctx context.Context, key string) ([]byte
 
 // AsyncGet 全异步操作
 func (c *ReadThroughCache) AsyncGet(ctx context.Context, key string) ([]byte, error) {
-	val, err := c.Cache.Get(ctx, key)
-	if err == cache.ErrKeyNotFound {
-		go func() {
-			if data, e2 := c.LoadFunc(ctx, key); e2 == nil {
-				e3 := c.Cache.Set(ctx, key, data, c.expiration)
-				if e3 != nil {
-					log.Fatalf("cache: refresh cache fail, %s", e3)
+	if c.isClosed() {
+		return nil, cache.ErrClosed
+	}
+	val, err := c.get(ctx, key)
+	if miss(err) {
+		var data []byte
+		var ttl time.Duration
+		c.async(ctx, key, func(ctx context.Context) error {
+			var e2 error
+			data, ttl, e2 = c.load(ctx, key)
+			return e2
+		}, func(ctx context.Context) error {
+			return c.Cache.Set(ctx, key, data, c.jitterTTL(ttl))
+		})
+	}
+	return val, err
+}
+
+// GetMulti 批量读取，只加载缓存中未命中的 key 并写回缓存，数据源中也不存在的 key 不出现在结果中
+func (c *ReadThroughCache) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	if c.isClosed() {
+		return nil, cache.ErrClosed
+	}
+	res, err := cache.AsBatch(c.Cache).GetMulti(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	missing := make([]string, 0, len(keys)-len(res))
+	for _, key := range keys {
+		if val, ok := res[key]; ok {
+			c.stats.Hit()
+			if isNegative(val) {
+				delete(res, key)
+			}
+		} else {
+			c.stats.Miss()
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return res, nil
+	}
+	loaded, ttls, err := c.loadMulti(ctx, missing)
+	if err != nil {
+		return res, err
+	}
+	for key, val := range loaded {
+		res[key] = val
+	}
+	if err = c.setMulti(ctx, loaded, ttls); err != nil {
+		return res, fmt.Errorf("cache: refresh cache fail, reason: %w", err)
+	}
+	return res, nil
+}
+
+// setMulti 过期时间相同的 key 一起写入，每组只随机浮动一次，避免 jitter 让每个 key 单独写入
+func (c *ReadThroughCache) setMulti(ctx context.Context, vals map[string][]byte, ttls map[string]time.Duration) error {
+	groups := make(map[time.Duration]map[string][]byte)
+	for key, val := range vals {
+		ttl := ttls[key]
+		if groups[ttl] == nil {
+			groups[ttl] = make(map[string][]byte)
+		}
+		groups[ttl][key] = val
+	}
+	for ttl, group := range groups {
+		if err := cache.AsBatch(c.Cache).SetMulti(ctx, group, c.jitterTTL(ttl)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadMulti 返回加载到的值和每个 key 的过期时间
+func (c *ReadThroughCache) loadMulti(ctx context.Context, keys []string) (map[string][]byte, map[string]time.Duration, error) {
+	if c.batchLoadFunc != nil {
+		if c.filter != nil {
+			keys = c.filterKeys(keys)
+			if len(keys) == 0 {
+				return map[string][]byte{}, nil, nil
+			}
+		}
+		start := time.Now()
+		res, err := c.batchLoadFunc(ctx, keys)
+		c.stats.Load(time.Since(start), err)
+		if err == nil && c.filter != nil {
+			for key := range res {
+				c.filter.Add(key)
+			}
+		}
+		if err == nil && c.negativeExpiration > 0 {
+			negatives := make(map[string][]byte, len(keys)-len(res))
+			for _, key := range keys {
+				if _, ok := res[key]; !ok {
+					negatives[key] = negativeValue
 				}
 			}
-		}()
+			_ = cache.AsBatch(c.Cache).SetMulti(ctx, negatives, c.negativeExpiration)
+		}
+		ttls := make(map[string]time.Duration, len(res))
+		for key, val := range res {
+			ttls[key] = c.ttl(key, val, 0)
+		}
+		return res, ttls, err
+	}
+	res := make(map[string][]byte, len(keys))
+	ttls := make(map[string]time.Duration, len(keys))
+	for _, key := range keys {
+		val, ttl, err := c.load(ctx, key)
+		if c.notExist(err) {
+			continue
+		}
+		if err != nil {
+			return res, ttls, err
+		}
+		res[key] = val
+		ttls[key] = ttl
+	}
+	return res, ttls, nil
+}
+
+// AddToFilter 数据源中新增了 key 但没有经过本缓存写入时调用，避免之后加载被 filter 拒绝，未配置 filter 时不做任何事
+func (c *ReadThroughCache) AddToFilter(keys ...string) {
+	if c.filter == nil {
+		return
+	}
+	for _, key := range keys {
+		c.filter.Add(key)
+	}
+}
+
+// filterKeys 去掉 filter 判断一定不存在的 key
+func (c *ReadThroughCache) filterKeys(keys []string) []string {
+	res := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if c.filter.Test(key) {
+			res = append(res, key)
+		}
+	}
+	return res
+}
+
+// Set 写入的 key 加入 filter，写入成功后同时更新保留的旧值
+func (c *ReadThroughCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
+	if c.isClosed() {
+		return cache.ErrClosed
+	}
+	c.AddToFilter(key)
+	if err := c.Cache.Set(ctx, key, val, expiration); err != nil {
+		return err
+	}
+	if c.grace != nil {
+		_ = c.grace.Set(ctx, key, val, c.graceTTL(expiration))
+	}
+	return nil
+}
+
+func (c *ReadThroughCache) SetMulti(ctx context.Context, vals map[string][]byte, expiration time.Duration) error {
+	if c.isClosed() {
+		return cache.ErrClosed
+	}
+	for key := range vals {
+		c.AddToFilter(key)
+	}
+	if err := cache.AsBatch(c.Cache).SetMulti(ctx, vals, expiration); err != nil {
+		return err
+	}
+	if c.grace != nil {
+		_ = cache.AsBatch(c.grace).SetMulti(ctx, vals, c.graceTTL(expiration))
+	}
+	return nil
+}
+
+func (c *ReadThroughCache) DeleteMulti(ctx context.Context, keys []string) error {
+	if c.isClosed() {
+		return cache.ErrClosed
+	}
+	if c.grace != nil {
+		_ = cache.AsBatch(c.grace).DeleteMulti(ctx, keys)
+	}
+	return cache.AsBatch(c.Cache).DeleteMulti(ctx, keys)
+}
+
+// Delete 同时删除保留的旧值，避免数据源故障时返回已删除的数据
+func (c *ReadThroughCache) Delete(ctx context.Context, key string) error {
+	if c.isClosed() {
+		return cache.ErrClosed
+	}
+	if c.grace != nil {
+		_ = c.grace.Delete(ctx, key)
+	}
+	return c.Cache.Delete(ctx, key)
+}
+
+// LoadAndDelete 删除的是不存在结果的占位值时返回 ErrNegativeCached
+func (c *ReadThroughCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	if c.isClosed() {
+		return nil, cache.ErrClosed
+	}
+	if c.grace != nil {
+		_ = c.grace.Delete(ctx, key)
+	}
+	val, err := c.Cache.LoadAndDelete(ctx, key)
+	if err == nil && isNegative(val) {
+		return nil, fmt.Errorf("%w, key: %s", ErrNegativeCached, key)
+	}
+	return val, err
+}
+
+// OnEvicted 不存在结果的占位值以 nil 回调
+func (c *ReadThroughCache) OnEvicted(fn func(key string, val []byte)) {
+	c.Cache.OnEvicted(func(key string, val []byte) {
+		if isNegative(val) {
+			val = nil
+		}
+		fn(key, val)
+	})
+}
+
+// get 读取底层缓存并统计命中，缓存的不存在结果返回 ErrNegativeCached
+func (c *ReadThroughCache) get(ctx context.Context, key string) ([]byte, error) {
+	val, err := c.Cache.Get(ctx, key)
+	switch {
+	case err == nil:
+		c.stats.Hit()
+		if isNegative(val) {
+			return nil, fmt.Errorf("%w, key: %s", ErrNegativeCached, key)
+		}
+	case errors.Is(err, cache.ErrKeyNotFound):
+		c.stats.Miss()
 	}
 	return val, err
 }
+
+// load 调用 LoadFunc 并统计耗时和结果，返回写入缓存使用的过期时间（未随机浮动），filter 判断不存在时不调用 LoadFunc；
+// 配置了 grace 时加载失败返回旧值，数据源中不存在时按配置缓存不存在结果
+func (c *ReadThroughCache) load(ctx context.Context, key string) ([]byte, time.Duration, error) {
+	if c.filter != nil && !c.filter.Test(key) {
+		return nil, 0, fmt.Errorf("%w, rejected by bloom filter, key: %s", cache.ErrKeyNotFound, key)
+	}
+	start := time.Now()
+	var val []byte
+	var ttl time.Duration
+	var err error
+	if c.loadWithTTLFunc != nil {
+		val, ttl, err = c.loadWithTTLFunc(ctx, key)
+	} else {
+		val, err = c.LoadFunc(ctx, key)
+	}
+	c.stats.Load(time.Since(start), err)
+	if err != nil && c.isNotFound(err) {
+		c.notFound(ctx, key)
+		if !errors.Is(err, cache.ErrKeyNotFound) {
+			err = &loadError{target: cache.ErrKeyNotFound, key: key, err: err}
+		}
+		return val, 0, err
+	}
+	if err == nil {
+		ttl = c.ttl(key, val, ttl)
+		if c.filter != nil {
+			c.filter.Add(key)
+		}
+	}
+	if c.grace == nil {
+		return val, ttl, err
+	}
+	if err == nil {
+		_ = c.grace.Set(ctx, key, val, c.graceTTL(ttl))
+		return val, ttl, nil
+	}
+	stale, e2 := c.grace.Get(ctx, key)
+	if e2 != nil {
+		return val, 0, err
+	}
+	return stale, 0, &loadError{target: cache.ErrServedStale, key: key, err: err}
+}
+
+// graceTTL 旧值比缓存多保留 maxStale，包括随机浮动的部分，缓存永不过期时旧值也不过期
+func (c *ReadThroughCache) graceTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return 0
+	}
+	return ttl + time.Duration(float64(ttl)*c.jitter) + c.maxStale
+}
+
+// ttl 计算写入缓存的过期时间，loaded 为加载时返回的过期时间，写入时再由 jitterTTL 随机浮动
+func (c *ReadThroughCache) ttl(key string, val []byte, loaded time.Duration) time.Duration {
+	res := loaded
+	if res <= 0 {
+		res = c.expiration
+		if c.ttlFunc != nil {
+			res = c.ttlFunc(key, val)
+		}
+	}
+	return res
+}
+
+func (c *ReadThroughCache) jitterTTL(ttl time.Duration) time.Duration {
+	return cache.JitterTTL(ttl, c.jitter)
+}
+
+// notFound 数据源中已不存在，移除保留的旧值并写入占位值
+func (c *ReadThroughCache) notFound(ctx context.Context, key string) {
+	if c.grace != nil {
+		_ = c.grace.Delete(ctx, key)
+	}
+	if c.negativeExpiration > 0 {
+		_ = c.Cache.Set(ctx, key, negativeValue, c.negativeExpiration)
+	}
+}
+
+// Stats 命中统计只包含经过本层的读取，Bytes 由底层缓存统计
+func (c *ReadThroughCache) Stats() metrics.Stats {
+	return c.stats.Snapshot()
+}
+
+// async 在后台依次执行 steps，每一步失败时按配置重试，最终仍失败时交给 errHandler 并跳过后续步骤，
+// 数据源中不存在的 key 不重试也不视为失败；返回任务是否提交成功
+func (c *ReadThroughCache) async(ctx context.Context, key string, steps ...func(ctx context.Context) error) bool {
+	run := func() {
+		ctx, cancel := c.asyncContext(ctx)
+		defer cancel()
+		for _, step := range steps {
+			err := async.Retry(ctx, c.maxRetry, c.backoff, step, c.retryable)
+			if c.notExist(err) {
+				return
+			}
+			if err != nil {
+				c.errHandler(ctx, key, err)
+				return
+			}
+		}
+	}
+	//登记和关闭在同一把锁下判断，Close 开始等待后不会再有新的任务
+	if !c.tracker.Add() {
+		c.errHandler(ctx, key, cache.ErrClosed)
+		return false
+	}
+	if c.pool != nil {
+		err := c.pool.Submit(ctx, key, run)
+		c.tracker.Done()
+		if err != nil {
+			c.errHandler(ctx, key, err)
+			return false
+		}
+		return true
+	}
+	go func() {
+		defer c.tracker.Done()
+		run()
+	}()
+	return true
+}
+
+func (c *ReadThroughCache) asyncContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = cache.DetachContext(ctx)
+	if c.asyncTimeout > 0 {
+		return context.WithTimeout(ctx, c.asyncTimeout)
+	}
+	return ctx, func() {}
+}
+
+// Wait 等待已提交的异步刷新全部完成，ctx 结束后不再等待；使用 pool 时只等待本缓存提交的任务
+func (c *ReadThroughCache) Wait(ctx context.Context) error {
+	if err := c.tracker.Wait(ctx); err != nil {
+		return err
+	}
+	if c.pool != nil {
+		return c.pool.Wait(ctx)
+	}
+	return nil
+}
+
+// Close 拒绝新的操作，等待未完成的异步刷新后关闭实现了 cache.Closer 的底层缓存；
+// ctx 结束导致等待失败时底层缓存尚未关闭，可以再次调用 Close
+func (c *ReadThroughCache) Close(ctx context.Context) error {
+	if !c.tracker.Close() {
+		return cache.ErrClosed
+	}
+	if err := c.Wait(ctx); err != nil {
+		return err
+	}
+	if !c.tracker.Release() {
+		return cache.ErrClosed
+	}
+	if closer, ok := c.Cache.(cache.Closer); ok {
+		return closer.Close(ctx)
+	}
+	return nil
+}
+
+func (c *ReadThroughCache) isClosed() bool {
+	return c.tracker.Closed()
+}
+
+// miss 缓存未命中，不包括缓存的不存在结果
+func miss(err error) bool {
+	return errors.Is(err, cache.ErrKeyNotFound) && !errors.Is(err, ErrNegativeCached)
+}
+
+// notExist 数据源中不存在，包括 filter 拒绝和满足 isNotFound 的加载错误
+func (c *ReadThroughCache) notExist(err error) bool {
+	return err != nil && (errors.Is(err, cache.ErrKeyNotFound) || c.isNotFound(err))
+}
+
+// retryable 数据源中不存在的 key 不重试
+func (c *ReadThroughCache) retryable(err error) bool {
+	return !c.notExist(err)
+}
+
+func isKeyNotFound(err error) bool {
+	return errors.Is(err, cache.ErrKeyNotFound)
+}
+
+func isNegative(val []byte) bool {
+	return bytes.Equal(val, negativeValue)
+}
+
+// loadError 包装加载错误，errors.Is 既能判断 target（cache.ErrServedStale、cache.ErrKeyNotFound），也能判断加载错误
+type loadError struct {
+	target error
+	key    string
+	err    error
+}
+
+func (e *loadError) Error() string {
+	return fmt.Sprintf("%s, key: %s, reason: %s", e.target.Error(), e.key, e.err.Error())
+}
+
+func (e *loadError) Is(target error) bool {
+	return target == e.target
+}
+
+func (e *loadError) Unwrap() error {
+	return e.err
+}
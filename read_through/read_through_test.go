@@ -5,7 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/bloom"
+	"github.com/ac-zht/cache/clock/fake_clock"
+	"github.com/ac-zht/cache/local_cache"
+	"github.com/ac-zht/cache/worker_pool"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -141,6 +148,691 @@ func TestReadThroughCache_AsyncGet(t *testing.T) {
 	}
 }
 
+func TestReadThroughCache_AsyncError(t *testing.T) {
+	testCase := []struct {
+		name        string
+		get         func(c *ReadThroughCache, ctx context.Context, key string) ([]byte, error)
+		setFailures int
+		loadErr     error
+		wantSets    int
+		wantLoads   int
+		wantHandled error
+		wantData    map[string][]byte
+	}{
+		{
+			name:        "semi async set fail after retry",
+			get:         (*ReadThroughCache).SemiAsyncGet,
+			setFailures: 10,
+			wantSets:    3,
+			wantLoads:   1,
+			wantHandled: errors.New("set error"),
+			wantData:    map[string][]byte{},
+		},
+		{
+			name:        "async set success after retry",
+			get:         (*ReadThroughCache).AsyncGet,
+			setFailures: 2,
+			wantSets:    3,
+			wantLoads:   1,
+			wantData:    map[string][]byte{"key": []byte("value")},
+		},
+		{
+			name:      "async load not found",
+			get:       (*ReadThroughCache).AsyncGet,
+			loadErr:   cache.ErrKeyNotFound,
+			wantLoads: 1,
+			wantData:  map[string][]byte{},
+		},
+		{
+			name:        "async load fail after retry",
+			get:         (*ReadThroughCache).AsyncGet,
+			loadErr:     errors.New("db down"),
+			wantLoads:   3,
+			wantHandled: errors.New("db down"),
+			wantData:    map[string][]byte{},
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			mc := &flakyCache{MockCache: MockCache{data: map[string][]byte{}}, failures: tc.setFailures}
+			loads := 0
+			var handled error
+			c := NewReadThroughCache(mc, time.Minute, func(ctx context.Context, key string) ([]byte, error) {
+				loads++
+				if tc.loadErr != nil {
+					return nil, tc.loadErr
+				}
+				return []byte("value"), nil
+			},
+				ReadThroughCacheWithRetry(2, time.Millisecond),
+				ReadThroughCacheWithErrorHandler(func(ctx context.Context, key string, err error) {
+					handled = err
+				}),
+			)
+			_, _ = tc.get(c, context.Background(), "key")
+			assert.NoError(t, c.Wait(context.Background()))
+			assert.Equal(t, tc.wantSets, mc.sets)
+			assert.Equal(t, tc.wantLoads, loads)
+			assert.Equal(t, tc.wantHandled, handled)
+			assert.Equal(t, tc.wantData, mc.data)
+		})
+	}
+}
+
+func TestReadThroughCache_Pool(t *testing.T) {
+	pool := worker_pool.NewPool(worker_pool.PoolWithWorkers(1), worker_pool.PoolWithQueueSize(1),
+		worker_pool.PoolWithOverflow(worker_pool.OverflowDrop))
+	gate, started := make(chan struct{}), make(chan struct{})
+	require.NoError(t, pool.Submit(context.Background(), "", func() {
+		close(started)
+		<-gate
+	}))
+	<-started
+	var handled []string
+	mc := &MockCache{data: map[string][]byte{}}
+	c := NewReadThroughCache(mc, time.Minute, func(ctx context.Context, key string) ([]byte, error) {
+		return []byte("value"), nil
+	},
+		ReadThroughCacheWithPool(pool),
+		ReadThroughCacheWithErrorHandler(func(ctx context.Context, key string, err error) {
+			assert.Equal(t, worker_pool.ErrDropped, err)
+			handled = append(handled, key)
+		}),
+	)
+	_, _ = c.AsyncGet(context.Background(), "k1")
+	_, _ = c.AsyncGet(context.Background(), "k1")
+	//队列已满，k2 被丢弃
+	_, _ = c.AsyncGet(context.Background(), "k2")
+	close(gate)
+	require.NoError(t, c.Wait(context.Background()))
+	assert.Equal(t, []string{"k2"}, handled)
+	assert.Equal(t, map[string][]byte{"k1": []byte("value")}, mc.data)
+}
+
+func TestReadThroughCache_StaleOnError(t *testing.T) {
+	dbErr := errors.New("db down")
+	testCase := []struct {
+		name      string
+		advance   time.Duration
+		loadErr   error
+		deleted   bool
+		wantVal   []byte
+		wantError error
+		wantCause error
+	}{
+		{
+			name:      "within max stale",
+			advance:   time.Minute * 2,
+			loadErr:   dbErr,
+			wantVal:   []byte("v1"),
+			wantError: cache.ErrServedStale,
+			wantCause: dbErr,
+		},
+		{
+			name:      "exceed max stale",
+			advance:   time.Minute * 4,
+			loadErr:   dbErr,
+			wantError: dbErr,
+		},
+		{
+			name:      "key not found",
+			advance:   time.Minute * 2,
+			loadErr:   cache.ErrKeyNotFound,
+			wantError: cache.ErrKeyNotFound,
+		},
+		{
+			name:      "deleted",
+			advance:   time.Minute * 2,
+			loadErr:   dbErr,
+			deleted:   true,
+			wantError: dbErr,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			clk := fake_clock.NewFakeClock(time.Now())
+			var loadErr error
+			c := NewReadThroughCache(local_cache.NewBytesCache(10, local_cache.BuildInMapCacheWithClock(clk)),
+				time.Minute,
+				func(ctx context.Context, key string) ([]byte, error) {
+					if loadErr != nil {
+						return nil, loadErr
+					}
+					return []byte("v1"), nil
+				},
+				ReadThroughCacheWithStaleOnError(local_cache.NewBytesCache(10, local_cache.BuildInMapCacheWithClock(clk)), time.Minute*2),
+			)
+			_, err := c.Get(context.Background(), "k1")
+			require.NoError(t, err)
+			if tc.deleted {
+				require.NoError(t, c.Delete(context.Background(), "k1"))
+			}
+
+			loadErr = tc.loadErr
+			clk.Advance(tc.advance)
+			val, err := c.Get(context.Background(), "k1")
+			assert.ErrorIs(t, err, tc.wantError)
+			if tc.wantCause != nil {
+				assert.ErrorIs(t, err, tc.wantCause)
+			}
+			assert.Equal(t, tc.wantVal, val)
+			//旧值不写回缓存
+			_, err = c.Cache.Get(context.Background(), "k1")
+			assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+		})
+	}
+}
+
+func TestReadThroughCache_StaleAfterSet(t *testing.T) {
+	clk := fake_clock.NewFakeClock(time.Now())
+	dbErr := errors.New("db down")
+	c := NewReadThroughCache(local_cache.NewBytesCache(10, local_cache.BuildInMapCacheWithClock(clk)),
+		time.Minute,
+		func(ctx context.Context, key string) ([]byte, error) {
+			return nil, dbErr
+		},
+		ReadThroughCacheWithStaleOnError(local_cache.NewBytesCache(10, local_cache.BuildInMapCacheWithClock(clk)), time.Minute*2),
+	)
+	require.NoError(t, c.Set(context.Background(), "k1", []byte("v1"), time.Minute))
+	require.NoError(t, c.SetMulti(context.Background(), map[string][]byte{"k2": []byte("v2")}, time.Minute))
+
+	clk.Advance(time.Minute * 2)
+	for key, want := range map[string]string{"k1": "v1", "k2": "v2"} {
+		val, err := c.Get(context.Background(), key)
+		assert.ErrorIs(t, err, cache.ErrServedStale)
+		assert.ErrorIs(t, err, dbErr)
+		assert.Equal(t, want, string(val))
+	}
+}
+
+func TestReadThroughCache_NegativeCache(t *testing.T) {
+	errNoRows := errors.New("no rows")
+	testCase := []struct {
+		name       string
+		loadErr    error
+		isNotFound func(err error) bool
+		advance    time.Duration
+		wantError  error
+		wantLoad   int
+	}{
+		{
+			name:      "negative cached",
+			loadErr:   cache.ErrKeyNotFound,
+			advance:   time.Second * 5,
+			wantError: ErrNegativeCached,
+			wantLoad:  1,
+		},
+		{
+			name:       "predicate",
+			loadErr:    errNoRows,
+			isNotFound: func(err error) bool { return errors.Is(err, errNoRows) },
+			advance:    time.Second * 5,
+			wantError:  ErrNegativeCached,
+			wantLoad:   1,
+		},
+		{
+			name:      "negative expired",
+			loadErr:   cache.ErrKeyNotFound,
+			advance:   time.Second * 11,
+			wantError: cache.ErrKeyNotFound,
+			wantLoad:  2,
+		},
+		{
+			name:      "other error not cached",
+			loadErr:   errNoRows,
+			advance:   time.Second * 5,
+			wantError: errNoRows,
+			wantLoad:  2,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			clk := fake_clock.NewFakeClock(time.Now())
+			load := 0
+			c := NewReadThroughCache(local_cache.NewBytesCache(10, local_cache.BuildInMapCacheWithClock(clk)), time.Minute,
+				func(ctx context.Context, key string) ([]byte, error) {
+					load++
+					return nil, tc.loadErr
+				}, ReadThroughCacheWithNegativeCache(time.Second*10, tc.isNotFound))
+			_, err := c.Get(context.Background(), "k1")
+			assert.ErrorIs(t, err, tc.loadErr)
+			if errors.Is(tc.wantError, ErrNegativeCached) {
+				//满足 isNotFound 的加载错误同样包装 cache.ErrKeyNotFound
+				assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+			}
+
+			clk.Advance(tc.advance)
+			_, err = c.Get(context.Background(), "k1")
+			assert.ErrorIs(t, err, tc.wantError)
+			assert.Equal(t, tc.wantLoad, load)
+			if errors.Is(tc.wantError, ErrNegativeCached) {
+				assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+			}
+		})
+	}
+}
+
+func TestReadThroughCache_NegativePlaceholder(t *testing.T) {
+	errNoRows := errors.New("no rows")
+	load := int32(0)
+	var handled error
+	c := NewReadThroughCache(local_cache.NewBytesCache(10), time.Minute,
+		func(ctx context.Context, key string) ([]byte, error) {
+			atomic.AddInt32(&load, 1)
+			return nil, errNoRows
+		},
+		ReadThroughCacheWithNegativeCache(time.Second*10, func(err error) bool { return errors.Is(err, errNoRows) }),
+		ReadThroughCacheWithRetry(3, time.Millisecond),
+		ReadThroughCacheWithErrorHandler(func(ctx context.Context, key string, err error) {
+			handled = err
+		}))
+	evicted := map[string][]byte{}
+	c.OnEvicted(func(key string, val []byte) {
+		evicted[key] = val
+	})
+	//异步加载时不存在的 key 不重试也不视为失败
+	_, err := c.AsyncGet(context.Background(), "k1")
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+	require.NoError(t, c.Wait(context.Background()))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&load))
+	assert.Nil(t, handled)
+
+	val, err := c.LoadAndDelete(context.Background(), "k1")
+	assert.ErrorIs(t, err, ErrNegativeCached)
+	assert.Nil(t, val)
+	assert.Equal(t, map[string][]byte{"k1": nil}, evicted)
+}
+
+func TestReadThroughCache_NegativeGetMulti(t *testing.T) {
+	var loaded []string
+	c := NewReadThroughCache(local_cache.NewBytesCache(10), time.Minute,
+		func(ctx context.Context, key string) ([]byte, error) {
+			return nil, errors.New("should not be called")
+		},
+		ReadThroughCacheWithBatchLoadFunc(func(ctx context.Context, keys []string) (map[string][]byte, error) {
+			loaded = append(loaded, keys...)
+			return map[string][]byte{"k1": []byte("v1")}, nil
+		}),
+		ReadThroughCacheWithNegativeCache(time.Second*10, nil))
+	res, err := c.GetMulti(context.Background(), []string{"k1", "k2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"k1": []byte("v1")}, res)
+
+	res, err = c.GetMulti(context.Background(), []string{"k1", "k2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"k1": []byte("v1")}, res)
+	assert.Equal(t, []string{"k1", "k2"}, loaded)
+	_, err = c.Get(context.Background(), "k2")
+	assert.ErrorIs(t, err, ErrNegativeCached)
+}
+
+func TestReadThroughCache_BloomFilter(t *testing.T) {
+	db := map[string][]byte{"k1": []byte("v1"), "k2": []byte("v2")}
+	var loaded []string
+	filter := bloom.NewBloomFilter(100, 0.01)
+	require.NoError(t, bloom.Populate(context.Background(), filter, func(ctx context.Context, add func(key string)) error {
+		add("k1")
+		return nil
+	}))
+	c := NewReadThroughCache(local_cache.NewBytesCache(10), time.Minute,
+		func(ctx context.Context, key string) ([]byte, error) {
+			loaded = append(loaded, key)
+			if val, ok := db[key]; ok {
+				return val, nil
+			}
+			return nil, cache.ErrKeyNotFound
+		}, ReadThroughCacheWithBloomFilter(filter))
+	val, err := c.Get(context.Background(), "k1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v1"), val)
+	//不在 filter 中的 key 不调用 LoadFunc
+	_, err = c.Get(context.Background(), "k2")
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+	_, err = c.Get(context.Background(), "k3")
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+	assert.Equal(t, []string{"k1"}, loaded)
+
+	//k2 绕过缓存写入数据源后加入 filter
+	c.AddToFilter("k2")
+	res, err := c.GetMulti(context.Background(), []string{"k2", "k3"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"k2": []byte("v2")}, res)
+	assert.Equal(t, []string{"k1", "k2"}, loaded)
+}
+
+func TestReadThroughCache_BloomFilterSet(t *testing.T) {
+	clk := fake_clock.NewFakeClock(time.Now())
+	db := map[string][]byte{}
+	c := NewReadThroughCache(local_cache.NewBytesCache(10, local_cache.BuildInMapCacheWithClock(clk)), time.Minute,
+		func(ctx context.Context, key string) ([]byte, error) {
+			if val, ok := db[key]; ok {
+				return val, nil
+			}
+			return nil, cache.ErrKeyNotFound
+		}, ReadThroughCacheWithBloomFilter(bloom.NewBloomFilter(100, 0.01)))
+	//经过缓存写入的 key 在缓存过期后仍能从数据源加载
+	db["k1"], db["k2"] = []byte("v1"), []byte("v2")
+	require.NoError(t, c.Set(context.Background(), "k1", []byte("v1"), time.Minute))
+	require.NoError(t, c.SetMulti(context.Background(), map[string][]byte{"k2": []byte("v2")}, time.Minute))
+	clk.Advance(time.Minute * 2)
+	for key, want := range map[string]string{"k1": "v1", "k2": "v2"} {
+		val, err := c.Get(context.Background(), key)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(val))
+	}
+}
+
+func TestReadThroughCache_TTL(t *testing.T) {
+	testCases := []struct {
+		name     string
+		loadFunc func(ctx context.Context, key string) ([]byte, error)
+		opts     []ReadThroughCacheOption
+		wantMin  time.Duration
+		wantMax  time.Duration
+	}{
+		{
+			name:    "fixed",
+			wantMin: time.Minute,
+			wantMax: time.Minute,
+		},
+		{
+			name:    "jitter",
+			opts:    []ReadThroughCacheOption{ReadThroughCacheWithTTLJitter(0.2)},
+			wantMin: time.Second * 48,
+			wantMax: time.Second * 72,
+		},
+		{
+			name: "ttl func",
+			opts: []ReadThroughCacheOption{ReadThroughCacheWithTTLFunc(func(key string, val []byte) time.Duration {
+				return time.Second * time.Duration(len(val))
+			})},
+			wantMin: time.Second * 5,
+			wantMax: time.Second * 5,
+		},
+		{
+			name: "load with ttl",
+			opts: []ReadThroughCacheOption{ReadThroughCacheWithLoadWithTTLFunc(func(ctx context.Context, key string) ([]byte, time.Duration, error) {
+				return []byte("value"), time.Hour, nil
+			}), ReadThroughCacheWithTTLJitter(0.1)},
+			wantMin: time.Minute * 54,
+			wantMax: time.Minute * 66,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mc := &ttlCache{MockCache: MockCache{data: map[string][]byte{}}, ttls: map[string]time.Duration{}}
+			c := NewSingleflightCache(mc, time.Minute, func(ctx context.Context, key string) ([]byte, error) {
+				return []byte("value"), nil
+			}, tc.opts...)
+			var ttls []time.Duration
+			for i := 0; i < 20; i++ {
+				key := strconv.Itoa(i)
+				val, err := c.Get(context.Background(), key)
+				require.NoError(t, err)
+				assert.Equal(t, []byte("value"), val)
+				ttls = append(ttls, mc.ttls[key])
+			}
+			res, err := c.GetMulti(context.Background(), []string{"m1", "m2", "m3"})
+			require.NoError(t, err)
+			assert.Len(t, res, 3)
+			//过期时间相同的 key 只随机浮动一次，一起写入
+			assert.Equal(t, 1, mc.batches)
+			assert.Equal(t, mc.ttls["m1"], mc.ttls["m2"])
+			assert.Equal(t, mc.ttls["m1"], mc.ttls["m3"])
+			ttls = append(ttls, mc.ttls["m1"])
+			for _, ttl := range ttls {
+				assert.GreaterOrEqual(t, ttl, tc.wantMin)
+				assert.LessOrEqual(t, ttl, tc.wantMax)
+			}
+		})
+	}
+}
+
+// ttlCache 记录每个 key 写入时的过期时间和 SetMulti 的调用次数
+type ttlCache struct {
+	MockCache
+	ttls    map[string]time.Duration
+	batches int
+}
+
+func (c *ttlCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
+	c.ttls[key] = expiration
+	return c.MockCache.Set(ctx, key, val, expiration)
+}
+
+func (c *ttlCache) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	return cache.AsBatch(&c.MockCache).GetMulti(ctx, keys)
+}
+
+func (c *ttlCache) SetMulti(ctx context.Context, vals map[string][]byte, expiration time.Duration) error {
+	c.batches++
+	for key, val := range vals {
+		if err := c.Set(ctx, key, val, expiration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ttlCache) DeleteMulti(ctx context.Context, keys []string) error {
+	return cache.AsBatch(&c.MockCache).DeleteMulti(ctx, keys)
+}
+
+type traceKey struct{}
+
+func TestReadThroughCache_DetachedContext(t *testing.T) {
+	mc := &ctxCache{MockCache: MockCache{data: map[string][]byte{}}}
+	c := NewReadThroughCache(mc, time.Minute, func(ctx context.Context, key string) ([]byte, error) {
+		time.Sleep(time.Millisecond * 20)
+		return []byte(fmt.Sprint(ctx.Value(traceKey{}))), ctx.Err()
+	}, ReadThroughCacheWithErrorHandler(func(ctx context.Context, key string, err error) {
+		t.Errorf("unexpected error: %v", err)
+	}))
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), traceKey{}, "trace-1"))
+	_, err := c.AsyncGet(ctx, "key")
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+	//请求结束，ctx 被取消，后台加载和写缓存仍然完成
+	cancel()
+	require.NoError(t, c.Wait(context.Background()))
+	assert.Equal(t, map[string][]byte{"key": []byte("trace-1")}, mc.data)
+}
+
+// ctxCache ctx 已取消时写入失败
+type ctxCache struct {
+	MockCache
+}
+
+func (c *ctxCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.MockCache.Set(ctx, key, val, expiration)
+}
+
+type flakyCache struct {
+	MockCache
+	failures int
+	sets     int
+}
+
+func (c *flakyCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
+	c.sets++
+	if c.sets <= c.failures {
+		return errors.New("set error")
+	}
+	return c.MockCache.Set(ctx, key, val, expiration)
+}
+
+func TestReadThroughCache_Close(t *testing.T) {
+	lc := local_cache.NewBytesCache(10)
+	c := NewReadThroughCache(lc, time.Minute, func(ctx context.Context, key string) ([]byte, error) {
+		time.Sleep(time.Millisecond * 50)
+		return []byte("value"), nil
+	})
+	_, err := c.AsyncGet(context.Background(), "key")
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+
+	//Close 等待异步刷新完成后再关闭底层缓存
+	assert.NoError(t, c.Close(context.Background()))
+	assert.Equal(t, []byte("value"), lc.Data["key"].Val())
+	_, err = c.Get(context.Background(), "key")
+	assert.Equal(t, cache.ErrClosed, err)
+	_, err = lc.Get(context.Background(), "key")
+	assert.Equal(t, cache.ErrClosed, err)
+	assert.Equal(t, cache.ErrClosed, c.Close(context.Background()))
+}
+
+func TestReadThroughCache_WriteAfterClose(t *testing.T) {
+	grace := local_cache.NewBytesCache(10)
+	c := NewReadThroughCache(&MockCache{data: map[string][]byte{}}, time.Minute,
+		func(ctx context.Context, key string) ([]byte, error) {
+			return []byte("value"), nil
+		}, ReadThroughCacheWithStaleOnError(grace, time.Minute))
+	require.NoError(t, c.Close(context.Background()))
+	assert.Equal(t, cache.ErrClosed, c.Set(context.Background(), "key", []byte("value"), time.Minute))
+	assert.Equal(t, cache.ErrClosed, c.Delete(context.Background(), "key"))
+	_, err := c.LoadAndDelete(context.Background(), "key")
+	assert.Equal(t, cache.ErrClosed, err)
+	//关闭后不再写入 grace
+	_, err = grace.Get(context.Background(), "key")
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+}
+
+func TestReadThroughCache_CloseTimeout(t *testing.T) {
+	lc := local_cache.NewBytesCache(10)
+	c := NewSingleflightCache(lc, time.Minute, func(ctx context.Context, key string) ([]byte, error) {
+		time.Sleep(time.Millisecond * 100)
+		return []byte("value"), nil
+	})
+	_, _ = c.AsyncGet(context.Background(), "key")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, c.Close(ctx))
+	_, err := c.Get(context.Background(), "key")
+	assert.Equal(t, cache.ErrClosed, err)
+
+	//等待超时后底层缓存未关闭，再次 Close 等待完成后关闭
+	assert.NoError(t, c.Close(context.Background()))
+	assert.Equal(t, []byte("value"), lc.Data["key"].Val())
+	_, err = lc.Get(context.Background(), "key")
+	assert.Equal(t, cache.ErrClosed, err)
+	assert.Equal(t, cache.ErrClosed, c.Close(context.Background()))
+}
+
+func TestReadThroughCache_GetMulti(t *testing.T) {
+	db := map[string][]byte{"k1": []byte("v1"), "k2": []byte("v2"), "k3": []byte("v3")}
+	testCase := []struct {
+		name      string
+		cache     func(loaded *[]string) *ReadThroughCache
+		keys      []string
+		wantVal   map[string][]byte
+		wantLoad  []string
+		wantError error
+	}{
+		{
+			name: "batch load missing keys",
+			cache: func(loaded *[]string) *ReadThroughCache {
+				return NewReadThroughCache(local_cache.NewBytesCache(10), time.Minute,
+					func(ctx context.Context, key string) ([]byte, error) {
+						return nil, errors.New("should not be called")
+					},
+					ReadThroughCacheWithBatchLoadFunc(func(ctx context.Context, keys []string) (map[string][]byte, error) {
+						*loaded = append(*loaded, keys...)
+						res := map[string][]byte{}
+						for _, key := range keys {
+							if val, ok := db[key]; ok {
+								res[key] = val
+							}
+						}
+						return res, nil
+					}),
+				)
+			},
+			keys:     []string{"k1", "k2", "k4"},
+			wantVal:  map[string][]byte{"k1": []byte("v1"), "k2": []byte("v2")},
+			wantLoad: []string{"k2", "k4"},
+		},
+		{
+			name: "fallback to load func",
+			cache: func(loaded *[]string) *ReadThroughCache {
+				return NewReadThroughCache(&MockCache{data: map[string][]byte{}}, time.Minute,
+					func(ctx context.Context, key string) ([]byte, error) {
+						*loaded = append(*loaded, key)
+						if val, ok := db[key]; ok {
+							return val, nil
+						}
+						return nil, cache.ErrKeyNotFound
+					},
+				)
+			},
+			keys:     []string{"k1", "k2", "k4"},
+			wantVal:  map[string][]byte{"k1": []byte("v1"), "k2": []byte("v2")},
+			wantLoad: []string{"k2", "k4"},
+		},
+		{
+			name: "batch load fail",
+			cache: func(loaded *[]string) *ReadThroughCache {
+				return NewReadThroughCache(local_cache.NewBytesCache(10), time.Minute, nil,
+					ReadThroughCacheWithBatchLoadFunc(func(ctx context.Context, keys []string) (map[string][]byte, error) {
+						*loaded = append(*loaded, keys...)
+						return nil, errors.New("db down")
+					}),
+				)
+			},
+			keys:      []string{"k1", "k2"},
+			wantVal:   map[string][]byte{"k1": []byte("v1")},
+			wantLoad:  []string{"k2"},
+			wantError: errors.New("db down"),
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			var loaded []string
+			c := tc.cache(&loaded)
+			//k1 已在缓存中
+			assert.NoError(t, c.Set(context.Background(), "k1", []byte("v1"), time.Minute))
+			vals, err := c.GetMulti(context.Background(), tc.keys)
+			assert.Equal(t, tc.wantError, err)
+			assert.Equal(t, tc.wantVal, vals)
+			assert.Equal(t, tc.wantLoad, loaded)
+			if err != nil {
+				return
+			}
+			//加载到的 key 已写回缓存，再次读取不会触发加载
+			loaded = nil
+			vals, err = c.GetMulti(context.Background(), tc.keys)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantVal, vals)
+			assert.Equal(t, []string{"k4"}, loaded)
+
+			assert.NoError(t, c.DeleteMulti(context.Background(), []string{"k1", "k2"}))
+			_, err = c.Cache.Get(context.Background(), "k1")
+			assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+		})
+	}
+}
+
+func TestReadThroughCache_Stats(t *testing.T) {
+	c := NewSingleflightCache(local_cache.NewBytesCache(10), time.Minute, func(ctx context.Context, key string) ([]byte, error) {
+		if key == "k3" {
+			return nil, errors.New("db down")
+		}
+		return []byte("value"), nil
+	})
+	ctx := context.Background()
+	_, _ = c.Get(ctx, "k1")
+	_, _ = c.Get(ctx, "k1")
+	_, _ = c.Get(ctx, "k3")
+	_, _ = c.GetMulti(ctx, []string{"k1", "k2"})
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(3), stats.Misses)
+	assert.Equal(t, uint64(2), stats.LoadSuccesses)
+	assert.Equal(t, uint64(1), stats.LoadFailures)
+	assert.Equal(t, uint64(3), stats.LoadLatency.Count)
+}
+
 type MockCache struct {
 	cache.Cache
 	data       map[string][]byte
@@ -0,0 +1,177 @@
+package read_through
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/clock"
+	"sync"
+	"time"
+)
+
+// RefreshAheadCache 缓存项超过软过期时间后，Get 立即返回旧值并在后台重新加载一次，
+// 超过硬过期时间后缓存项被移除，Get 同步加载。同一个 key 的后台加载与同步加载共用 singleflight，
+// softTTL 不小于 hardTTL 时不会触发后台加载
+type RefreshAheadCache struct {
+	SingleflightCache
+	envelope *envelopeCache
+	//refreshing 已提交后台加载的 key，同一个 key 同时只提交一个后台加载
+	mutex      *sync.Mutex
+	refreshing map[string]struct{}
+}
+
+// NewRefreshAheadCache hardTTL 为写入底层缓存的过期时间，底层缓存中保存的值带有软过期时间前缀，
+// 不要绕过 RefreshAheadCache 直接读写底层缓存
+func NewRefreshAheadCache(c cache.Cache, softTTL, hardTTL time.Duration,
+	LoadFunc func(ctx context.Context, key string) ([]byte, error), opts ...ReadThroughCacheOption) *RefreshAheadCache {
+	env := &envelopeCache{Cache: c, softTTL: softTTL, hardTTL: hardTTL}
+	res := &RefreshAheadCache{
+		SingleflightCache: *NewSingleflightCache(env, hardTTL, LoadFunc, opts...),
+		envelope:          env,
+		mutex:             &sync.Mutex{},
+		refreshing:        map[string]struct{}{},
+	}
+	env.clock = res.clock
+	return res
+}
+
+func (r *RefreshAheadCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if r.isClosed() {
+		return nil, cache.ErrClosed
+	}
+	val, softDeadline, err := r.envelope.getEntry(ctx, key)
+	if err == nil {
+		r.stats.Hit()
+		if r.clock.Now().After(softDeadline) {
+			r.refresh(ctx, key)
+		}
+		if isNegative(val) {
+			return nil, fmt.Errorf("%w, key: %s", ErrNegativeCached, key)
+		}
+		return val, nil
+	}
+	if !errors.Is(err, cache.ErrKeyNotFound) {
+		return nil, err
+	}
+	r.stats.Miss()
+	return r.loadOnce(ctx, key)
+}
+
+// SemiAsyncGet 软过期后本身就在后台加载，与 Get 相同
+func (r *RefreshAheadCache) SemiAsyncGet(ctx context.Context, key string) ([]byte, error) {
+	return r.Get(ctx, key)
+}
+
+// AsyncGet 与 Get 相同
+func (r *RefreshAheadCache) AsyncGet(ctx context.Context, key string) ([]byte, error) {
+	return r.Get(ctx, key)
+}
+
+// refresh 在后台重新加载，同一个 key 已有后台加载未完成时跳过，执行前缓存已被其他调用刷新时也跳过
+func (r *RefreshAheadCache) refresh(ctx context.Context, key string) {
+	r.mutex.Lock()
+	if _, ok := r.refreshing[key]; ok {
+		r.mutex.Unlock()
+		return
+	}
+	r.refreshing[key] = struct{}{}
+	r.mutex.Unlock()
+	ok := r.async(ctx, key, func(ctx context.Context) error {
+		defer r.refreshed(key)
+		_, softDeadline, err := r.envelope.getEntry(ctx, key)
+		if err == nil && !r.clock.Now().After(softDeadline) {
+			return nil
+		}
+		_, err = r.loadOnce(ctx, key)
+		return err
+	})
+	if !ok {
+		r.refreshed(key)
+	}
+}
+
+func (r *RefreshAheadCache) refreshed(key string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.refreshing, key)
+}
+
+// envelopeHeaderSize 软过期时间，8 字节大端 UnixNano
+const envelopeHeaderSize = 8
+
+var errMalformedEnvelope = fmt.Errorf("%w, malformed refresh ahead entry", cache.ErrKeyNotFound)
+
+// envelopeCache 写入时在值前加上软过期时间，读取时去掉，无法解析的值视为不存在
+type envelopeCache struct {
+	cache.Cache
+	softTTL time.Duration
+	hardTTL time.Duration
+	clock   clock.Clock
+}
+
+func (e *envelopeCache) Get(ctx context.Context, key string) ([]byte, error) {
+	val, _, err := e.getEntry(ctx, key)
+	return val, err
+}
+
+func (e *envelopeCache) getEntry(ctx context.Context, key string) ([]byte, time.Time, error) {
+	data, err := e.Cache.Get(ctx, key)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return decodeEnvelope(data)
+}
+
+func (e *envelopeCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
+	return e.Cache.Set(ctx, key, encodeEnvelope(val, e.clock.Now().Add(e.soft(expiration))), expiration)
+}
+
+// soft 实际过期时间与 hardTTL 不同时（按 key 计算、随机浮动或不存在结果）按比例缩放软过期时间，
+// 保证软过期仍早于硬过期
+func (e *envelopeCache) soft(expiration time.Duration) time.Duration {
+	if expiration <= 0 || expiration == e.hardTTL || e.softTTL >= e.hardTTL {
+		return e.softTTL
+	}
+	return time.Duration(float64(e.softTTL) * float64(expiration) / float64(e.hardTTL))
+}
+
+func (e *envelopeCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	data, err := e.Cache.LoadAndDelete(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	val, _, err := decodeEnvelope(data)
+	return val, err
+}
+
+// OnEvicted 无法解析的值（例如远程缓存只通知 key）以 nil 回调
+func (e *envelopeCache) OnEvicted(fn func(key string, val []byte)) {
+	e.Cache.OnEvicted(func(key string, data []byte) {
+		val, _, _ := decodeEnvelope(data)
+		fn(key, val)
+	})
+}
+
+func (e *envelopeCache) Close(ctx context.Context) error {
+	if closer, ok := e.Cache.(cache.Closer); ok {
+		return closer.Close(ctx)
+	}
+	return nil
+}
+
+func encodeEnvelope(val []byte, softDeadline time.Time) []byte {
+	res := make([]byte, envelopeHeaderSize+len(val))
+	binary.BigEndian.PutUint64(res, uint64(softDeadline.UnixNano()))
+	copy(res[envelopeHeaderSize:], val)
+	return res
+}
+
+func decodeEnvelope(data []byte) ([]byte, time.Time, error) {
+	if len(data) < envelopeHeaderSize {
+		return nil, time.Time{}, errMalformedEnvelope
+	}
+	softDeadline := time.Unix(0, int64(binary.BigEndian.Uint64(data)))
+	return data[envelopeHeaderSize:], softDeadline, nil
+}
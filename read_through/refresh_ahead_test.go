@@ -0,0 +1,156 @@
+package read_through
+
+import (
+	"context"
+	"errors"
+	"github.com/ac-zht/cache/clock/fake_clock"
+	"github.com/ac-zht/cache/local_cache"
+	"github.com/ac-zht/cache/worker_pool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRefreshAheadCache_Get(t *testing.T) {
+	testCases := []struct {
+		name     string
+		advance  time.Duration
+		wantVal  string
+		wantLoad int32
+	}{
+		{
+			name:     "fresh",
+			advance:  time.Second,
+			wantVal:  "v1",
+			wantLoad: 1,
+		},
+		{
+			name:     "stale",
+			advance:  time.Second * 6,
+			wantVal:  "v1",
+			wantLoad: 2,
+		},
+		{
+			name:     "expired",
+			advance:  time.Second * 11,
+			wantVal:  "v2",
+			wantLoad: 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clk := fake_clock.NewFakeClock(time.Now())
+			cnt := int32(0)
+			c := NewRefreshAheadCache(local_cache.NewBytesCache(10, local_cache.BuildInMapCacheWithClock(clk)),
+				time.Second*5, time.Second*10, func(ctx context.Context, key string) ([]byte, error) {
+					return []byte("v" + strconv.Itoa(int(atomic.AddInt32(&cnt, 1)))), nil
+				}, ReadThroughCacheWithClock(clk))
+			val, err := c.Get(context.Background(), "key")
+			require.NoError(t, err)
+			assert.Equal(t, "v1", string(val))
+
+			clk.Advance(tc.advance)
+			val, err = c.Get(context.Background(), "key")
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantVal, string(val))
+			require.NoError(t, c.Wait(context.Background()))
+			assert.Equal(t, tc.wantLoad, atomic.LoadInt32(&cnt))
+
+			val, err = c.Get(context.Background(), "key")
+			require.NoError(t, err)
+			assert.Equal(t, "v"+strconv.Itoa(int(tc.wantLoad)), string(val))
+		})
+	}
+}
+
+func TestRefreshAheadCache_ConcurrentStale(t *testing.T) {
+	clk := fake_clock.NewFakeClock(time.Now())
+	cnt := int32(0)
+	gate := make(chan struct{})
+	pool := worker_pool.NewPool(worker_pool.PoolWithWorkers(2))
+	defer pool.Close(context.Background())
+	c := NewRefreshAheadCache(local_cache.NewBytesCache(10, local_cache.BuildInMapCacheWithClock(clk)),
+		time.Second, time.Minute, func(ctx context.Context, key string) ([]byte, error) {
+			if atomic.AddInt32(&cnt, 1) > 1 {
+				<-gate
+			}
+			return []byte("value"), nil
+		}, ReadThroughCacheWithClock(clk), ReadThroughCacheWithPool(pool))
+	_, err := c.Get(context.Background(), "key")
+	require.NoError(t, err)
+
+	clk.Advance(time.Second * 2)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val, err := c.Get(context.Background(), "key")
+			assert.NoError(t, err)
+			assert.Equal(t, "value", string(val))
+		}()
+	}
+	wg.Wait()
+	//后台加载未完成前只提交一次
+	assert.Equal(t, uint64(1), pool.Stats().Submitted)
+	close(gate)
+	require.NoError(t, c.Wait(context.Background()))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&cnt))
+
+	//后台加载完成后再次软过期时可以重新提交
+	clk.Advance(time.Second * 2)
+	_, err = c.Get(context.Background(), "key")
+	require.NoError(t, err)
+	require.NoError(t, c.Wait(context.Background()))
+	assert.Equal(t, uint64(2), pool.Stats().Submitted)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&cnt))
+}
+
+func TestRefreshAheadCache_ShorterTTL(t *testing.T) {
+	clk := fake_clock.NewFakeClock(time.Now())
+	cnt := int32(0)
+	c := NewRefreshAheadCache(local_cache.NewBytesCache(10, local_cache.BuildInMapCacheWithClock(clk)),
+		time.Second*5, time.Second*10, func(ctx context.Context, key string) ([]byte, error) {
+			return []byte("v" + strconv.Itoa(int(atomic.AddInt32(&cnt, 1)))), nil
+		}, ReadThroughCacheWithClock(clk), ReadThroughCacheWithTTLFunc(func(key string, val []byte) time.Duration {
+			return time.Second * 4
+		}))
+	_, err := c.Get(context.Background(), "key")
+	require.NoError(t, err)
+
+	//硬过期时间为 4s，软过期时间按比例缩短为 2s
+	clk.Advance(time.Second * 3)
+	val, err := c.Get(context.Background(), "key")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(val))
+	require.NoError(t, c.Wait(context.Background()))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&cnt))
+}
+
+func TestRefreshAheadCache_RefreshFail(t *testing.T) {
+	clk := fake_clock.NewFakeClock(time.Now())
+	var handled error
+	c := NewRefreshAheadCache(local_cache.NewBytesCache(10, local_cache.BuildInMapCacheWithClock(clk)),
+		time.Second, time.Minute, func(ctx context.Context, key string) ([]byte, error) {
+			//后台刷新保留调用方 ctx 中的值
+			if ctx.Value(traceKey{}) != nil {
+				return nil, errors.New("db down")
+			}
+			return []byte("value"), nil
+		}, ReadThroughCacheWithClock(clk), ReadThroughCacheWithErrorHandler(func(ctx context.Context, key string, err error) {
+			handled = err
+		}))
+	_, err := c.Get(context.Background(), "key")
+	require.NoError(t, err)
+
+	clk.Advance(time.Second * 2)
+	val, err := c.Get(context.WithValue(context.Background(), traceKey{}, "trace"), "key")
+	require.NoError(t, err)
+	assert.Equal(t, "value", string(val))
+	require.NoError(t, c.Wait(context.Background()))
+	assert.EqualError(t, handled, "db down")
+}
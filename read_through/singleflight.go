@@ -14,31 +14,36 @@ type SingleflightCache struct {
 }
 
 func NewSingleflightCache(cache cache.Cache, expiration time.Duration,
-	LoadFunc func(ctx context.Context, key string) ([]byte, error)) *SingleflightCache {
+	LoadFunc func(ctx context.Context, key string) ([]byte, error), opts ...ReadThroughCacheOption) *SingleflightCache {
 	return &SingleflightCache{
-		ReadThroughCache: ReadThroughCache{
-			Cache:      cache,
-			expiration: expiration,
-			LoadFunc:   LoadFunc,
-		},
-		g: &singleflight.Group{},
+		ReadThroughCache: *NewReadThroughCache(cache, expiration, LoadFunc, opts...),
+		g:                &singleflight.Group{},
 	}
 }
 
 func (s *SingleflightCache) Get(ctx context.Context, key string) ([]byte, error) {
-	v1, e1 := s.Cache.Get(ctx, key)
-	if e1 == cache.ErrKeyNotFound {
-		v2, e2, _ := s.g.Do(key, func() (interface{}, error) {
-			val, err := s.LoadFunc(ctx, key)
-			if err == nil {
-				err = s.Cache.Set(ctx, key, val, s.expiration)
-				if err != nil {
-					return val, fmt.Errorf("%w, reason: %s", cache.NewErrRefreshCacheFail(key), err.Error())
-				}
-			}
-			return val, err
-		})
-		return v2.([]byte), e2
+	if s.isClosed() {
+		return nil, cache.ErrClosed
+	}
+	v1, e1 := s.get(ctx, key)
+	if miss(e1) {
+		return s.loadOnce(ctx, key)
 	}
 	return v1, e1
 }
+
+// loadOnce 同一个 key 同时只有一个调用执行加载并写缓存，其余调用等待并共享结果
+func (s *SingleflightCache) loadOnce(ctx context.Context, key string) ([]byte, error) {
+	v2, e2, _ := s.g.Do(key, func() (interface{}, error) {
+		val, ttl, err := s.load(ctx, key)
+		if err == nil {
+			err = s.Cache.Set(ctx, key, val, s.jitterTTL(ttl))
+			if err != nil {
+				return val, fmt.Errorf("%w, reason: %s", cache.NewErrRefreshCacheFail(key), err.Error())
+			}
+		}
+		return val, err
+	})
+	val, _ := v2.([]byte)
+	return val, e2
+}
@@ -2,8 +2,11 @@ package read_through
 
 import (
 	"context"
+	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/local_cache"
 	"github.com/stretchr/testify/assert"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -33,6 +36,30 @@ func TestSingleflightCache_Get(t *testing.T) {
 	assert.True(t, all)
 }
 
+func TestSingleflightCache_NegativeCache(t *testing.T) {
+	cnt := int32(0)
+	c := NewSingleflightCache(local_cache.NewBytesCache(10), time.Minute,
+		func(ctx context.Context, key string) ([]byte, error) {
+			atomic.AddInt32(&cnt, 1)
+			time.Sleep(time.Millisecond * 10)
+			return nil, cache.ErrKeyNotFound
+		}, ReadThroughCacheWithNegativeCache(time.Second*10, nil))
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val, err := c.Get(context.Background(), "key")
+			assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+			assert.Nil(t, val)
+		}()
+	}
+	wg.Wait()
+	_, err := c.Get(context.Background(), "key")
+	assert.ErrorIs(t, err, ErrNegativeCached)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&cnt))
+}
+
 type MockCacheV2 struct {
 	MockCache
 }
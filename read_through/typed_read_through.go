@@ -0,0 +1,39 @@
+package read_through
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ac-zht/cache"
+	"time"
+)
+
+// TypedReadThroughCache 泛型 read through 缓存
+type TypedReadThroughCache[K comparable, V any] struct {
+	cache.TypedCache[K, V]
+	expiration time.Duration
+	LoadFunc   func(ctx context.Context, key K) (V, error)
+}
+
+func NewTypedReadThroughCache[K comparable, V any](c cache.TypedCache[K, V], expiration time.Duration,
+	LoadFunc func(ctx context.Context, key K) (V, error)) *TypedReadThroughCache[K, V] {
+	return &TypedReadThroughCache[K, V]{
+		TypedCache: c,
+		expiration: expiration,
+		LoadFunc:   LoadFunc,
+	}
+}
+
+// Get 同步操作
+func (c *TypedReadThroughCache[K, V]) Get(ctx context.Context, key K) (V, error) {
+	val, err := c.TypedCache.Get(ctx, key)
+	if errors.Is(err, cache.ErrKeyNotFound) {
+		if val, err = c.LoadFunc(ctx, key); err == nil {
+			err2 := c.TypedCache.Set(ctx, key, val, c.expiration)
+			if err2 != nil {
+				return val, cache.NewErrRefreshCacheFail(fmt.Sprint(key))
+			}
+		}
+	}
+	return val, err
+}
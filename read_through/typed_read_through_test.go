@@ -0,0 +1,73 @@
+package read_through
+
+import (
+	"context"
+	"errors"
+	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/local_cache"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type user struct {
+	Id   int64
+	Name string
+}
+
+func TestTypedReadThroughCache_Get(t *testing.T) {
+	testCase := []struct {
+		name      string
+		loadFunc  func(ctx context.Context, key int64) (user, error)
+		before    func(c cache.TypedCache[int64, user])
+		key       int64
+		wantVal   user
+		wantError error
+	}{
+		{
+			name: "cache exist",
+			loadFunc: func(ctx context.Context, key int64) (user, error) {
+				return user{}, errors.New("not found")
+			},
+			before: func(c cache.TypedCache[int64, user]) {
+				_ = c.Set(context.Background(), 1, user{Id: 1, Name: "Tom"}, time.Minute)
+			},
+			key:     1,
+			wantVal: user{Id: 1, Name: "Tom"},
+		},
+		{
+			name: "load success",
+			loadFunc: func(ctx context.Context, key int64) (user, error) {
+				return user{Id: key, Name: "Jerry"}, nil
+			},
+			before:  func(c cache.TypedCache[int64, user]) {},
+			key:     2,
+			wantVal: user{Id: 2, Name: "Jerry"},
+		},
+		{
+			name: "load fail",
+			loadFunc: func(ctx context.Context, key int64) (user, error) {
+				return user{}, errors.New("not found")
+			},
+			before:    func(c cache.TypedCache[int64, user]) {},
+			key:       3,
+			wantError: errors.New("not found"),
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			lc := local_cache.NewTypedBuildInMapCache[int64, user](10)
+			tc.before(lc)
+			c := NewTypedReadThroughCache[int64, user](lc, time.Minute, tc.loadFunc)
+			val, err := c.Get(context.Background(), tc.key)
+			assert.Equal(t, tc.wantError, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantVal, val)
+			val, err = lc.Get(context.Background(), tc.key)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantVal, val)
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package redis_cache
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis 进程内的 RESP 服务端，只实现 RedisCache 用到的命令
+type fakeRedis struct {
+	ln       net.Listener
+	mutex    *sync.Mutex
+	data     map[string]*fakeEntry
+	subs     map[string][]*fakeSubscriber
+	commands []string
+	//evalReply 不为空时 EVAL 直接返回该回复，模拟脚本返回其他类型
+	evalReply string
+}
+
+type fakeEntry struct {
+	val      []byte
+	deadline time.Time
+}
+
+type fakeSubscriber struct {
+	mutex *sync.Mutex
+	w     *bufio.Writer
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeRedis{
+		ln:    ln,
+		mutex: &sync.Mutex{},
+		data:  map[string]*fakeEntry{},
+		subs:  map[string][]*fakeSubscriber{},
+	}
+	go s.serve()
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+	return s
+}
+
+func (s *fakeRedis) addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *fakeRedis) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	sub := &fakeSubscriber{mutex: &sync.Mutex{}, w: bufio.NewWriter(conn)}
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+		args, ok := reply.([]any)
+		if !ok || len(args) == 0 {
+			return
+		}
+		strs := make([]string, len(args))
+		for i, arg := range args {
+			strs[i] = string(arg.([]byte))
+		}
+		sub.mutex.Lock()
+		s.exec(sub, strs)
+		_ = sub.w.Flush()
+		sub.mutex.Unlock()
+	}
+}
+
+func (s *fakeRedis) exec(sub *fakeSubscriber, args []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.commands = append(s.commands, strings.ToUpper(args[0]))
+	w := sub.w
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		_, _ = w.WriteString("+PONG\r\n")
+	case "AUTH", "SELECT":
+		_, _ = w.WriteString("+OK\r\n")
+	case "GET":
+		writeBulk(w, s.get(args[1]))
+	case "SET":
+		e := &fakeEntry{val: []byte(args[2])}
+		if len(args) == 5 && strings.ToUpper(args[3]) == "PX" {
+			ms, _ := strconv.Atoi(args[4])
+			if ms <= 0 {
+				_, _ = w.WriteString("-ERR invalid expire time in 'set' command\r\n")
+				return
+			}
+			e.deadline = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		s.data[args[1]] = e
+		_, _ = w.WriteString("+OK\r\n")
+	case "DEL":
+		_, ok := s.data[args[1]]
+		delete(s.data, args[1])
+		if ok {
+			_, _ = w.WriteString(":1\r\n")
+		} else {
+			_, _ = w.WriteString(":0\r\n")
+		}
+	case "GETDEL":
+		writeBulk(w, s.get(args[1]))
+		delete(s.data, args[1])
+	case "EVAL":
+		if s.evalReply != "" {
+			_, _ = w.WriteString(s.evalReply)
+			return
+		}
+		if args[1] != loadAndDeleteScript {
+			_, _ = w.WriteString("-ERR unknown script\r\n")
+			return
+		}
+		writeBulk(w, s.get(args[3]))
+		delete(s.data, args[3])
+	case "SUBSCRIBE":
+		for i, ch := range args[1:] {
+			s.subs[ch] = append(s.subs[ch], sub)
+			_, _ = fmt.Fprintf(w, "*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:%d\r\n", len(ch), ch, i+1)
+		}
+	default:
+		_, _ = fmt.Fprintf(w, "-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+func (s *fakeRedis) get(key string) []byte {
+	e, ok := s.data[key]
+	if !ok {
+		return nil
+	}
+	if !e.deadline.IsZero() && e.deadline.Before(time.Now()) {
+		delete(s.data, key)
+		return nil
+	}
+	return e.val
+}
+
+// expire 模拟服务端过期，删除 key 并发布 expired 键事件
+func (s *fakeRedis) expire(key string) {
+	s.mutex.Lock()
+	delete(s.data, key)
+	ch := "__keyevent@0__:expired"
+	subs := s.subs[ch]
+	s.mutex.Unlock()
+	for _, sub := range subs {
+		sub.mutex.Lock()
+		_, _ = fmt.Fprintf(sub.w, "*3\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(ch), ch, len(key), key)
+		_ = sub.w.Flush()
+		sub.mutex.Unlock()
+	}
+}
+
+func (s *fakeRedis) subscribers(ch string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.subs[ch])
+}
+
+func (s *fakeRedis) entry(key string) (*fakeEntry, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, ok := s.data[key]
+	return e, ok
+}
+
+func writeBulk(w *bufio.Writer, val []byte) {
+	if val == nil {
+		_, _ = w.WriteString("$-1\r\n")
+		return
+	}
+	_, _ = fmt.Fprintf(w, "$%d\r\n", len(val))
+	_, _ = w.Write(val)
+	_, _ = w.WriteString("\r\n")
+}
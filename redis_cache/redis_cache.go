@@ -0,0 +1,295 @@
+package redis_cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ac-zht/cache"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var _ cache.Cache = &RedisCache{}
+
+// loadAndDeleteScript 不支持 GETDEL 的服务端（6.2 以下）使用 Lua 脚本原子地读取并删除
+const loadAndDeleteScript = `local v = redis.call('GET', KEYS[1])
+if v then redis.call('DEL', KEYS[1]) end
+return v`
+
+type RedisCacheOption func(cache *RedisCache)
+
+// RedisCache 基于 RESP 协议实现的 redis 缓存，过期时间通过 SET PX 设置，
+// 开启键空间通知后，服务端过期或淘汰的 key 也会触发 OnEvicted 回调，此时 val 为 nil
+type RedisCache struct {
+	addr        string
+	password    string
+	db          int
+	dialTimeout time.Duration
+	maxIdle     int
+	useLua      bool
+	events      bool
+
+	pool *pool
+
+	mutex     *sync.RWMutex
+	onEvicted func(key string, val []byte)
+	closed    bool
+	close     chan struct{}
+	sub       *respConn
+	wg        *sync.WaitGroup
+}
+
+func NewRedisCache(addr string, opts ...RedisCacheOption) *RedisCache {
+	res := &RedisCache{
+		addr:        addr,
+		dialTimeout: time.Second * 3,
+		maxIdle:     16,
+		mutex:       &sync.RWMutex{},
+		onEvicted:   func(key string, val []byte) {},
+		close:       make(chan struct{}),
+		wg:          &sync.WaitGroup{},
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+	res.pool = newPool(res.maxIdle, res.dial)
+	if res.events {
+		res.wg.Add(1)
+		go res.subscribe()
+	}
+	return res
+}
+
+func RedisCacheWithPassword(password string) RedisCacheOption {
+	return func(cache *RedisCache) {
+		cache.password = password
+	}
+}
+
+func RedisCacheWithDB(db int) RedisCacheOption {
+	return func(cache *RedisCache) {
+		cache.db = db
+	}
+}
+
+func RedisCacheWithDialTimeout(timeout time.Duration) RedisCacheOption {
+	return func(cache *RedisCache) {
+		cache.dialTimeout = timeout
+	}
+}
+
+// RedisCacheWithMaxIdle 连接池中保留的空闲连接数量
+func RedisCacheWithMaxIdle(maxIdle int) RedisCacheOption {
+	return func(cache *RedisCache) {
+		cache.maxIdle = maxIdle
+	}
+}
+
+// RedisCacheWithLuaLoadAndDelete 使用 Lua 脚本代替 GETDEL
+func RedisCacheWithLuaLoadAndDelete() RedisCacheOption {
+	return func(cache *RedisCache) {
+		cache.useLua = true
+	}
+}
+
+// RedisCacheWithKeyspaceEvents 订阅 expired、evicted 键事件，
+// 需要服务端配置 notify-keyspace-events 至少包含 Exe
+func RedisCacheWithKeyspaceEvents() RedisCacheOption {
+	return func(cache *RedisCache) {
+		cache.events = true
+	}
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	reply, err := c.do(ctx, "GET", key)
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, fmt.Errorf("%w, key: %s", cache.ErrKeyNotFound, key)
+	}
+	val, ok := reply.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w, unexpected reply: %v", errProtocol, reply)
+	}
+	return val, nil
+}
+
+func (c *RedisCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
+	args := []string{"SET", key, string(val)}
+	if expiration > 0 {
+		//不足 1ms 时 PX 为 0 会被 redis 拒绝，按 1ms 处理
+		ms := expiration.Milliseconds()
+		if ms == 0 {
+			ms = 1
+		}
+		args = append(args, "PX", strconv.FormatInt(ms, 10))
+	}
+	_, err := c.do(ctx, args...)
+	return err
+}
+
+// Delete 借助 GETDEL 拿到被删除的值以便触发回调
+func (c *RedisCache) Delete(ctx context.Context, key string) error {
+	_, err := c.LoadAndDelete(ctx, key)
+	if errors.Is(err, cache.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (c *RedisCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	var (
+		reply any
+		err   error
+	)
+	if c.useLua {
+		reply, err = c.do(ctx, "EVAL", loadAndDeleteScript, "1", key)
+	} else {
+		reply, err = c.do(ctx, "GETDEL", key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, fmt.Errorf("%w, key: %s", cache.ErrKeyNotFound, key)
+	}
+	//脚本或代理返回了其他类型的回复
+	val, ok := reply.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w, unexpected reply: %v", errProtocol, reply)
+	}
+	c.evicted(key, val)
+	return val, nil
+}
+
+func (c *RedisCache) OnEvicted(fn func(key string, val []byte)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.onEvicted = fn
+}
+
+// Close 关闭连接池和订阅连接，之后的操作返回 cache.ErrClosed
+func (c *RedisCache) Close(ctx context.Context) error {
+	c.mutex.Lock()
+	if c.closed {
+		c.mutex.Unlock()
+		return cache.ErrClosed
+	}
+	c.closed = true
+	close(c.close)
+	if c.sub != nil {
+		_ = c.sub.Close()
+	}
+	c.mutex.Unlock()
+	c.pool.close()
+	c.wg.Wait()
+	return nil
+}
+
+func (c *RedisCache) do(ctx context.Context, args ...string) (any, error) {
+	if c.isClosed() {
+		return nil, cache.ErrClosed
+	}
+	conn, err := c.pool.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := conn.do(ctx, toBytes(args)...)
+	c.pool.put(conn, err)
+	return reply, err
+}
+
+func (c *RedisCache) dial(ctx context.Context) (*respConn, error) {
+	dialer := &net.Dialer{Timeout: c.dialTimeout}
+	nc, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, err
+	}
+	conn := newRespConn(nc)
+	if c.password != "" {
+		if _, err = conn.do(ctx, toBytes([]string{"AUTH", c.password})...); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	if c.db != 0 {
+		if _, err = conn.do(ctx, toBytes([]string{"SELECT", strconv.Itoa(c.db)})...); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// subscribe 订阅键事件，连接断开后每秒重连一次直到 Close
+func (c *RedisCache) subscribe() {
+	defer c.wg.Done()
+	expired := fmt.Sprintf("__keyevent@%d__:expired", c.db)
+	evicted := fmt.Sprintf("__keyevent@%d__:evicted", c.db)
+	for {
+		conn, err := c.dial(context.Background())
+		if err == nil {
+			c.mutex.Lock()
+			if c.closed {
+				c.mutex.Unlock()
+				_ = conn.Close()
+				return
+			}
+			c.sub = conn
+			c.mutex.Unlock()
+			err = conn.send(toBytes([]string{"SUBSCRIBE", expired, evicted})...)
+			for err == nil {
+				var reply any
+				reply, err = conn.receive()
+				if err == nil {
+					c.handleMessage(reply)
+				}
+			}
+			_ = conn.Close()
+		}
+		select {
+		case <-c.close:
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// handleMessage 处理 ["message", channel, key] 形式的推送
+func (c *RedisCache) handleMessage(reply any) {
+	msg, ok := reply.([]any)
+	if !ok || len(msg) != 3 {
+		return
+	}
+	kind, ok := msg[0].([]byte)
+	if !ok || string(kind) != "message" {
+		return
+	}
+	if key, ok := msg[2].([]byte); ok {
+		c.evicted(string(key), nil)
+	}
+}
+
+func (c *RedisCache) evicted(key string, val []byte) {
+	c.mutex.RLock()
+	fn := c.onEvicted
+	c.mutex.RUnlock()
+	fn(key, val)
+}
+
+func (c *RedisCache) isClosed() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.closed
+}
+
+func toBytes(args []string) [][]byte {
+	res := make([][]byte, len(args))
+	for i, arg := range args {
+		res[i] = []byte(arg)
+	}
+	return res
+}
@@ -0,0 +1,153 @@
+package redis_cache
+
+import (
+	"context"
+	"github.com/ac-zht/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRedisCache_Get(t *testing.T) {
+	testCase := []struct {
+		name      string
+		before    func(c *RedisCache)
+		key       string
+		wantVal   []byte
+		wantError error
+	}{
+		{
+			name: "get",
+			before: func(c *RedisCache) {
+				_ = c.Set(context.Background(), "key", []byte("value"), time.Minute)
+			},
+			key:     "key",
+			wantVal: []byte("value"),
+		},
+		{
+			name:      "not exist",
+			before:    func(c *RedisCache) {},
+			key:       "key",
+			wantError: cache.ErrKeyNotFound,
+		},
+		{
+			name: "expired",
+			before: func(c *RedisCache) {
+				_ = c.Set(context.Background(), "key", []byte("value"), time.Millisecond)
+				time.Sleep(time.Millisecond * 5)
+			},
+			key:       "key",
+			wantError: cache.ErrKeyNotFound,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newFakeRedis(t)
+			c := NewRedisCache(s.addr())
+			defer c.Close(context.Background())
+			tc.before(c)
+			val, err := c.Get(context.Background(), tc.key)
+			assert.ErrorIs(t, err, tc.wantError)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantVal, val)
+		})
+	}
+}
+
+func TestRedisCache_Set(t *testing.T) {
+	s := newFakeRedis(t)
+	c := NewRedisCache(s.addr(), RedisCacheWithDB(1), RedisCacheWithPassword("pwd"))
+	defer c.Close(context.Background())
+	require.NoError(t, c.Set(context.Background(), "k1", []byte("v1"), time.Minute))
+	require.NoError(t, c.Set(context.Background(), "k2", []byte("v2"), 0))
+	require.NoError(t, c.Set(context.Background(), "k3", []byte("v3"), time.Microsecond))
+
+	e, ok := s.entry("k1")
+	require.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), e.deadline, time.Second)
+	e, ok = s.entry("k2")
+	require.True(t, ok)
+	assert.True(t, e.deadline.IsZero())
+	e, ok = s.entry("k3")
+	require.True(t, ok)
+	assert.False(t, e.deadline.IsZero())
+	assert.Equal(t, []string{"AUTH", "SELECT", "SET", "SET", "SET"}, s.commands)
+}
+
+func TestRedisCache_LoadAndDelete(t *testing.T) {
+	testCase := []struct {
+		name         string
+		opts         []RedisCacheOption
+		wantCommands []string
+	}{
+		{
+			name:         "getdel",
+			wantCommands: []string{"SET", "GETDEL", "GETDEL"},
+		},
+		{
+			name:         "lua",
+			opts:         []RedisCacheOption{RedisCacheWithLuaLoadAndDelete()},
+			wantCommands: []string{"SET", "EVAL", "EVAL"},
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newFakeRedis(t)
+			c := NewRedisCache(s.addr(), tc.opts...)
+			defer c.Close(context.Background())
+			evicted := map[string][]byte{}
+			c.OnEvicted(func(key string, val []byte) {
+				evicted[key] = val
+			})
+			_ = c.Set(context.Background(), "key", []byte("value"), time.Minute)
+			val, err := c.LoadAndDelete(context.Background(), "key")
+			require.NoError(t, err)
+			assert.Equal(t, []byte("value"), val)
+			_, err = c.LoadAndDelete(context.Background(), "key")
+			assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+			assert.Equal(t, map[string][]byte{"key": []byte("value")}, evicted)
+			assert.Equal(t, tc.wantCommands, s.commands)
+		})
+	}
+}
+
+func TestRedisCache_UnexpectedReply(t *testing.T) {
+	s := newFakeRedis(t)
+	s.evalReply = ":1\r\n"
+	c := NewRedisCache(s.addr(), RedisCacheWithLuaLoadAndDelete())
+	defer c.Close(context.Background())
+	_, err := c.LoadAndDelete(context.Background(), "key")
+	assert.ErrorIs(t, err, errProtocol)
+}
+
+func TestRedisCache_KeyspaceEvents(t *testing.T) {
+	s := newFakeRedis(t)
+	c := NewRedisCache(s.addr(), RedisCacheWithKeyspaceEvents())
+	mutex := &sync.Mutex{}
+	evicted := map[string][]byte{}
+	c.OnEvicted(func(key string, val []byte) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		evicted[key] = val
+	})
+	_ = c.Set(context.Background(), "key", []byte("value"), time.Minute)
+	require.Eventually(t, func() bool {
+		return s.subscribers("__keyevent@0__:expired") == 1
+	}, time.Second, time.Millisecond)
+
+	s.expire("key")
+	require.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		val, ok := evicted["key"]
+		return ok && val == nil
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, c.Close(context.Background()))
+	_, err := c.Get(context.Background(), "key")
+	assert.Equal(t, cache.ErrClosed, err)
+}
@@ -0,0 +1,213 @@
+package redis_cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// RedisError 服务端返回的错误回复
+type RedisError string
+
+func (e RedisError) Error() string {
+	return "redis: " + string(e)
+}
+
+var errProtocol = errors.New("redis: protocol error")
+
+const (
+	//maxBulkLen 与 redis 的 proto-max-bulk-len 默认值一致
+	maxBulkLen = 512 << 20
+	//maxArrayLen 数组回复的元素数量上限，避免异常的长度导致一次分配过多内存
+	maxArrayLen = 1 << 24
+)
+
+// respConn 基于 RESP 协议的单个连接，非并发安全
+type respConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+func newRespConn(conn net.Conn) *respConn {
+	return &respConn{
+		conn: conn,
+		r:    bufio.NewReader(conn),
+		w:    bufio.NewWriter(conn),
+	}
+}
+
+// do 发送一条命令并读取回复，服务端错误以 RedisError 返回
+func (c *respConn) do(ctx context.Context, args ...[]byte) (any, error) {
+	if err := c.setDeadline(ctx); err != nil {
+		return nil, err
+	}
+	if err := c.send(args...); err != nil {
+		return nil, err
+	}
+	return c.receive()
+}
+
+func (c *respConn) setDeadline(ctx context.Context) error {
+	dl, _ := ctx.Deadline()
+	return c.conn.SetDeadline(dl)
+}
+
+func (c *respConn) send(args ...[]byte) error {
+	if err := writeCommand(c.w, args...); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
+
+func (c *respConn) receive() (any, error) {
+	return readReply(c.r)
+}
+
+func (c *respConn) Close() error {
+	return c.conn.Close()
+}
+
+func writeCommand(w *bufio.Writer, args ...[]byte) error {
+	if _, err := fmt.Fprintf(w, "*%d\r\n", len(args)); err != nil {
+		return err
+	}
+	for _, arg := range args {
+		if _, err := fmt.Fprintf(w, "$%d\r\n", len(arg)); err != nil {
+			return err
+		}
+		if _, err := w.Write(arg); err != nil {
+			return err
+		}
+		if _, err := w.WriteString("\r\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readReply 解析一条回复：简单字符串返回 string，整数返回 int64，
+// 批量字符串返回 []byte，数组返回 []any，空值返回 nil
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errProtocol
+	}
+	switch line[0] {
+	case '+':
+		return string(line[1:]), nil
+	case '-':
+		return nil, RedisError(line[1:])
+	case ':':
+		return strconv.ParseInt(string(line[1:]), 10, 64)
+	case '$':
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil {
+			return nil, errProtocol
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		if n > maxBulkLen {
+			return nil, errProtocol
+		}
+		buf := make([]byte, n+2)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		if buf[n] != '\r' || buf[n+1] != '\n' {
+			return nil, errProtocol
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil {
+			return nil, errProtocol
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		if n > maxArrayLen {
+			return nil, errProtocol
+		}
+		res := make([]any, n)
+		for i := range res {
+			if res[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	default:
+		return nil, errProtocol
+	}
+}
+
+func readLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return nil, errProtocol
+	}
+	return line[:len(line)-2], nil
+}
+
+// pool 简单的连接池，空闲连接数量不超过 maxIdle
+type pool struct {
+	dial   func(ctx context.Context) (*respConn, error)
+	idle   chan *respConn
+	closed int32
+}
+
+func newPool(maxIdle int, dial func(ctx context.Context) (*respConn, error)) *pool {
+	return &pool{
+		dial: dial,
+		idle: make(chan *respConn, maxIdle),
+	}
+}
+
+func (p *pool) get(ctx context.Context) (*respConn, error) {
+	select {
+	case c := <-p.idle:
+		return c, nil
+	default:
+		return p.dial(ctx)
+	}
+}
+
+// put 网络错误的连接直接关闭，服务端错误不影响连接复用
+func (p *pool) put(c *respConn, err error) {
+	var redisErr RedisError
+	if (err != nil && !errors.As(err, &redisErr)) || atomic.LoadInt32(&p.closed) == 1 {
+		_ = c.Close()
+		return
+	}
+	_ = c.conn.SetDeadline(time.Time{})
+	select {
+	case p.idle <- c:
+	default:
+		_ = c.Close()
+	}
+}
+
+func (p *pool) close() {
+	atomic.StoreInt32(&p.closed, 1)
+	for {
+		select {
+		case c := <-p.idle:
+			_ = c.Close()
+		default:
+			return
+		}
+	}
+}
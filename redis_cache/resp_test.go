@@ -0,0 +1,74 @@
+package redis_cache
+
+import (
+	"bufio"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestReadReply(t *testing.T) {
+	testCase := []struct {
+		name      string
+		input     string
+		wantReply any
+		wantError error
+	}{
+		{
+			name:      "simple string",
+			input:     "+OK\r\n",
+			wantReply: "OK",
+		},
+		{
+			name:      "error",
+			input:     "-ERR wrong type\r\n",
+			wantError: RedisError("ERR wrong type"),
+		},
+		{
+			name:      "integer",
+			input:     ":10\r\n",
+			wantReply: int64(10),
+		},
+		{
+			name:      "bulk string",
+			input:     "$5\r\nvalue\r\n",
+			wantReply: []byte("value"),
+		},
+		{
+			name:  "nil bulk string",
+			input: "$-1\r\n",
+		},
+		{
+			name:      "array",
+			input:     "*2\r\n$1\r\na\r\n:1\r\n",
+			wantReply: []any{[]byte("a"), int64(1)},
+		},
+		{
+			name:      "protocol error",
+			input:     "?\r\n",
+			wantError: errProtocol,
+		},
+		{
+			name:      "bulk string too large",
+			input:     "$9999999999\r\n",
+			wantError: errProtocol,
+		},
+		{
+			name:      "bulk string without crlf",
+			input:     "$5\r\nvalue!!",
+			wantError: errProtocol,
+		},
+		{
+			name:      "array too large",
+			input:     "*9999999999\r\n",
+			wantError: errProtocol,
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			reply, err := readReply(bufio.NewReader(strings.NewReader(tc.input)))
+			assert.Equal(t, tc.wantError, err)
+			assert.Equal(t, tc.wantReply, reply)
+		})
+	}
+}
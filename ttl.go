@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// jitterRand 全局 math/rand 在 go1.20 之前默认使用固定种子，每次启动产生相同的序列，这里使用单独播种的随机源
+var (
+	jitterMutex = &sync.Mutex{}
+	jitterRand  = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// JitterTTL 返回 [ttl*(1-percent), ttl*(1+percent)] 内均匀分布的随机过期时间，避免同时写入的 key 同时过期。
+// ttl 不大于 0（不过期）或 percent 不在 (0, 1) 内时原样返回
+func JitterTTL(ttl time.Duration, percent float64) time.Duration {
+	if ttl <= 0 || percent <= 0 || percent >= 1 {
+		return ttl
+	}
+	jitterMutex.Lock()
+	f := jitterRand.Float64()
+	jitterMutex.Unlock()
+	delta := float64(ttl) * percent * (2*f - 1)
+	return ttl + time.Duration(delta)
+}
@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestJitterTTL(t *testing.T) {
+	testCases := []struct {
+		name    string
+		ttl     time.Duration
+		percent float64
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{name: "jitter", ttl: time.Minute, percent: 0.1, wantMin: time.Second * 54, wantMax: time.Second * 66},
+		{name: "no jitter", ttl: time.Minute, wantMin: time.Minute, wantMax: time.Minute},
+		{name: "invalid percent", ttl: time.Minute, percent: 1, wantMin: time.Minute, wantMax: time.Minute},
+		{name: "no expiration", percent: 0.1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			seen := map[time.Duration]struct{}{}
+			for i := 0; i < 100; i++ {
+				ttl := JitterTTL(tc.ttl, tc.percent)
+				assert.GreaterOrEqual(t, ttl, tc.wantMin)
+				assert.LessOrEqual(t, ttl, tc.wantMax)
+				seen[ttl] = struct{}{}
+			}
+			if tc.wantMin != tc.wantMax {
+				assert.Greater(t, len(seen), 1)
+			}
+		})
+	}
+}
@@ -9,6 +9,9 @@ import (
 
 var (
 	ErrKeyNotFound = errors.New("cache: key not exist")
+	ErrClosed      = errors.New("cache: cache closed")
+	// ErrServedStale 数据源加载失败，返回的是已过期的旧值
+	ErrServedStale = errors.New("cache: served stale value")
 )
 
 func NewErrKeyNotFound(key string) error {
@@ -26,3 +29,49 @@ type Cache interface {
 	LoadAndDelete(ctx context.Context, key string) ([]byte, error)
 	OnEvicted(fn func(key string, val []byte))
 }
+
+// Closer 持有后台 goroutine 或连接的缓存实现该接口，关闭后的操作返回 ErrClosed
+type Closer interface {
+	Close(ctx context.Context) error
+}
+
+// BatchCache 批量操作，GetMulti 只返回命中的 key，未命中的 key 不视为错误
+type BatchCache interface {
+	GetMulti(ctx context.Context, keys []string) (map[string][]byte, error)
+	SetMulti(ctx context.Context, vals map[string][]byte, expiration time.Duration) error
+	DeleteMulti(ctx context.Context, keys []string) error
+}
+
+// TypedCache 泛型缓存接口，Cache 等价于 TypedCache[string, []byte]
+type TypedCache[K comparable, V any] interface {
+	Get(ctx context.Context, key K) (V, error)
+	Set(ctx context.Context, key K, val V, expiration time.Duration) error
+	Delete(ctx context.Context, key K) error
+	LoadAndDelete(ctx context.Context, key K) (V, error)
+	OnEvicted(fn func(key K, val V))
+}
+
+// EvictReason 缓存项被移除的原因
+type EvictReason int
+
+const (
+	// EvictReasonDeleted 调用 Delete、LoadAndDelete 主动删除
+	EvictReasonDeleted EvictReason = iota
+	// EvictReasonExpired 过期
+	EvictReasonExpired
+	// EvictReasonCapacity 容量已满被淘汰
+	EvictReasonCapacity
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictReasonDeleted:
+		return "deleted"
+	case EvictReasonExpired:
+		return "expired"
+	case EvictReasonCapacity:
+		return "capacity"
+	default:
+		return "unknown"
+	}
+}
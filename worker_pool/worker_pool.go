@@ -0,0 +1,288 @@
+package worker_pool
+
+import (
+	"context"
+	"errors"
+	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/internal/async"
+	"sync"
+)
+
+// ErrDropped OverflowDrop 模式下队列已满时返回
+var ErrDropped = errors.New("worker_pool: queue full, task dropped")
+
+// Overflow 队列已满时的处理方式
+type Overflow int
+
+const (
+	// OverflowBlock 阻塞直到队列有空位或 ctx 结束
+	OverflowBlock Overflow = iota
+	// OverflowDrop 丢弃任务并返回 ErrDropped
+	OverflowDrop
+	// OverflowSync 在调用方的 goroutine 中同步执行
+	OverflowSync
+)
+
+type PoolOption func(p *Pool)
+
+// Pool 固定数量的 worker 从有界队列中取任务执行，
+// 同一个 Group 中同一个 key 的任务还在排队时再次提交只替换待执行的函数，不占用新的队列位置
+type Pool struct {
+	workers  int
+	overflow Overflow
+	queue    chan *task
+
+	mutex   *sync.Mutex
+	pending map[pendingKey]*task
+	closed  bool
+	stats   Stats
+	//group 直接通过 Pool 提交的任务所属的 Group，groups 已创建的 Group 数量
+	group  *Group
+	groups uint64
+
+	//inflight 已入队和执行中的任务，submitting 正在提交的调用
+	inflight   *async.Tracker
+	submitting *sync.WaitGroup
+	workerWg   *sync.WaitGroup
+	done       chan struct{}
+	stop       chan struct{}
+}
+
+// Group 共享 Pool 的一个提交方，合并只在同一个 Group 内进行，Wait 只等待本 Group 的任务
+type Group struct {
+	pool     *Pool
+	id       uint64
+	inflight *async.Tracker
+}
+
+type pendingKey struct {
+	group uint64
+	key   string
+}
+
+type task struct {
+	group *Group
+	key   string
+	fn    func()
+}
+
+// Stats 队列统计，QueueDepth 为当前排队的任务数，MaxQueueDepth 为出现过的最大值
+type Stats struct {
+	QueueDepth    int
+	MaxQueueDepth int
+	QueueSize     int
+	Submitted     uint64
+	Coalesced     uint64
+	Dropped       uint64
+	SyncRuns      uint64
+	Completed     uint64
+}
+
+func NewPool(opts ...PoolOption) *Pool {
+	res := &Pool{
+		workers:    16,
+		overflow:   OverflowBlock,
+		queue:      make(chan *task, 1024),
+		mutex:      &sync.Mutex{},
+		pending:    map[pendingKey]*task{},
+		inflight:   async.NewTracker(),
+		submitting: &sync.WaitGroup{},
+		workerWg:   &sync.WaitGroup{},
+		done:       make(chan struct{}),
+		stop:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+	res.group = res.NewGroup()
+	res.stats.QueueSize = cap(res.queue)
+	for i := 0; i < res.workers; i++ {
+		res.workerWg.Add(1)
+		go res.work()
+	}
+	return res
+}
+
+func PoolWithWorkers(n int) PoolOption {
+	return func(p *Pool) {
+		p.workers = n
+	}
+}
+
+func PoolWithQueueSize(size int) PoolOption {
+	return func(p *Pool) {
+		p.queue = make(chan *task, size)
+	}
+}
+
+func PoolWithOverflow(overflow Overflow) PoolOption {
+	return func(p *Pool) {
+		p.overflow = overflow
+	}
+}
+
+// NewGroup 创建一个提交方，多个缓存共享 Pool 时各自使用一个 Group，相同 key 的任务互不合并
+func (p *Pool) NewGroup() *Group {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.groups++
+	return &Group{
+		pool:     p,
+		id:       p.groups,
+		inflight: async.NewTracker(),
+	}
+}
+
+// Submit 通过 Pool 自身的 Group 提交任务，见 Group.Submit
+func (p *Pool) Submit(ctx context.Context, key string, fn func()) error {
+	return p.group.Submit(ctx, key, fn)
+}
+
+// Submit 提交任务，key 为空时不合并。本 Group 中同一个 key 已有任务在排队时替换为 fn 并返回 nil，
+// 被替换的函数不会执行；OverflowBlock 模式下等待入队的任务因 ctx 结束或关闭而放弃时，合并进来的函数也不会执行
+func (g *Group) Submit(ctx context.Context, key string, fn func()) error {
+	p := g.pool
+	p.mutex.Lock()
+	if p.closed {
+		p.mutex.Unlock()
+		return cache.ErrClosed
+	}
+	p.stats.Submitted++
+	if t, ok := p.pending[pendingKey{group: g.id, key: key}]; ok && key != "" {
+		t.fn = fn
+		p.stats.Coalesced++
+		p.mutex.Unlock()
+		return nil
+	}
+	t := &task{group: g, key: key, fn: fn}
+	select {
+	case p.queue <- t:
+		p.register(t)
+		p.mutex.Unlock()
+		return nil
+	default:
+	}
+
+	switch p.overflow {
+	case OverflowDrop:
+		p.stats.Dropped++
+		p.mutex.Unlock()
+		return ErrDropped
+	case OverflowSync:
+		p.stats.SyncRuns++
+		p.mutex.Unlock()
+		fn()
+		return nil
+	}
+	//阻塞前先登记，入队前同 key 的提交也会合并到 t
+	p.register(t)
+	p.submitting.Add(1)
+	p.mutex.Unlock()
+	defer p.submitting.Done()
+	var err error
+	select {
+	case p.queue <- t:
+		return nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-p.done:
+		err = cache.ErrClosed
+	}
+	p.mutex.Lock()
+	p.unregister(t)
+	p.mutex.Unlock()
+	p.finish(t)
+	return err
+}
+
+// Wait 等待本 Group 已入队的任务全部执行完，ctx 结束后不再等待
+func (g *Group) Wait(ctx context.Context) error {
+	return g.inflight.Wait(ctx)
+}
+
+// register 登记已入队或即将入队的任务，调用方需持有锁
+func (p *Pool) register(t *task) {
+	p.inflight.Add()
+	t.group.inflight.Add()
+	if t.key != "" {
+		p.pending[pendingKey{group: t.group.id, key: t.key}] = t
+	}
+	if depth := len(p.queue); depth > p.stats.MaxQueueDepth {
+		p.stats.MaxQueueDepth = depth
+	}
+}
+
+// unregister 调用方需持有锁
+func (p *Pool) unregister(t *task) {
+	k := pendingKey{group: t.group.id, key: t.key}
+	if p.pending[k] == t {
+		delete(p.pending, k)
+	}
+}
+
+func (p *Pool) finish(t *task) {
+	t.group.inflight.Done()
+	p.inflight.Done()
+}
+
+func (p *Pool) work() {
+	defer p.workerWg.Done()
+	for {
+		select {
+		case t := <-p.queue:
+			p.run(t)
+		case <-p.stop:
+			//关闭时执行完队列中剩余的任务
+			for {
+				select {
+				case t := <-p.queue:
+					p.run(t)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (p *Pool) run(t *task) {
+	defer p.finish(t)
+	p.mutex.Lock()
+	p.unregister(t)
+	fn := t.fn
+	p.mutex.Unlock()
+
+	fn()
+
+	p.mutex.Lock()
+	p.stats.Completed++
+	p.mutex.Unlock()
+}
+
+func (p *Pool) Stats() Stats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	res := p.stats
+	res.QueueDepth = len(p.queue)
+	return res
+}
+
+// Wait 等待所有 Group 已入队的任务全部执行完，ctx 结束后不再等待
+func (p *Pool) Wait(ctx context.Context) error {
+	return p.inflight.Wait(ctx)
+}
+
+// Close 拒绝新任务，执行完队列中剩余的任务后停止 worker，ctx 结束后不再等待
+func (p *Pool) Close(ctx context.Context) error {
+	p.mutex.Lock()
+	if p.closed {
+		p.mutex.Unlock()
+		return cache.ErrClosed
+	}
+	p.closed = true
+	p.mutex.Unlock()
+	close(p.done)
+	p.submitting.Wait()
+	close(p.stop)
+	return async.Wait(ctx, p.workerWg)
+}
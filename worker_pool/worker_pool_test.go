@@ -0,0 +1,200 @@
+package worker_pool
+
+import (
+	"context"
+	"github.com/ac-zht/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blocked 返回一个占住唯一 worker 的池，调用 release 后 worker 继续执行
+func blocked(t *testing.T, opts ...PoolOption) (p *Pool, release func()) {
+	p = NewPool(append([]PoolOption{PoolWithWorkers(1)}, opts...)...)
+	gate := make(chan struct{})
+	started := make(chan struct{})
+	require.NoError(t, p.Submit(context.Background(), "", func() {
+		close(started)
+		<-gate
+	}))
+	<-started
+	once := &sync.Once{}
+	return p, func() {
+		once.Do(func() {
+			close(gate)
+		})
+	}
+}
+
+func TestPool_Submit(t *testing.T) {
+	p := NewPool(PoolWithWorkers(4), PoolWithQueueSize(8))
+	var cnt int64
+	for i := 0; i < 100; i++ {
+		require.NoError(t, p.Submit(context.Background(), "", func() {
+			atomic.AddInt64(&cnt, 1)
+		}))
+	}
+	require.NoError(t, p.Wait(context.Background()))
+	assert.Equal(t, int64(100), atomic.LoadInt64(&cnt))
+	stats := p.Stats()
+	assert.Equal(t, uint64(100), stats.Submitted)
+	assert.Equal(t, uint64(100), stats.Completed)
+	assert.Equal(t, 0, stats.QueueDepth)
+	assert.Equal(t, 8, stats.QueueSize)
+	require.NoError(t, p.Close(context.Background()))
+}
+
+func TestPool_Coalesce(t *testing.T) {
+	p, release := blocked(t)
+	mutex := &sync.Mutex{}
+	var got []string
+	submit := func(key, val string) {
+		require.NoError(t, p.Submit(context.Background(), key, func() {
+			mutex.Lock()
+			defer mutex.Unlock()
+			got = append(got, key+"="+val)
+		}))
+	}
+	submit("a", "1")
+	submit("b", "1")
+	submit("a", "2")
+	submit("a", "3")
+	assert.Equal(t, 2, p.Stats().QueueDepth)
+	release()
+	require.NoError(t, p.Wait(context.Background()))
+	assert.Equal(t, []string{"a=3", "b=1"}, got)
+	assert.Equal(t, uint64(2), p.Stats().Coalesced)
+
+	//已开始执行的任务不再合并
+	submit("a", "4")
+	require.NoError(t, p.Wait(context.Background()))
+	assert.Equal(t, []string{"a=3", "b=1", "a=4"}, got)
+}
+
+func TestPool_Group(t *testing.T) {
+	p, release := blocked(t)
+	g1, g2 := p.NewGroup(), p.NewGroup()
+	mutex := &sync.Mutex{}
+	var got []string
+	submit := func(g *Group, val string) {
+		require.NoError(t, g.Submit(context.Background(), "key", func() {
+			mutex.Lock()
+			defer mutex.Unlock()
+			got = append(got, val)
+		}))
+	}
+	//不同 Group 中相同的 key 不合并
+	submit(g1, "g1-1")
+	submit(g2, "g2-1")
+	submit(g1, "g1-2")
+	assert.Equal(t, 2, p.Stats().QueueDepth)
+
+	//Group 只等待自己的任务
+	gate := make(chan struct{})
+	require.NoError(t, g2.Submit(context.Background(), "slow", func() {
+		<-gate
+	}))
+	release()
+	require.NoError(t, g1.Wait(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, g2.Wait(ctx))
+	close(gate)
+	require.NoError(t, p.Wait(context.Background()))
+	assert.ElementsMatch(t, []string{"g1-2", "g2-1"}, got)
+}
+
+func TestPool_Overflow(t *testing.T) {
+	testCase := []struct {
+		name      string
+		overflow  Overflow
+		ctx       func() (context.Context, context.CancelFunc)
+		wantRun   bool
+		wantError error
+		wantStats func(s Stats) bool
+	}{
+		{
+			name:      "drop",
+			overflow:  OverflowDrop,
+			wantError: ErrDropped,
+			wantStats: func(s Stats) bool { return s.Dropped == 1 },
+		},
+		{
+			name:      "sync",
+			overflow:  OverflowSync,
+			wantRun:   true,
+			wantStats: func(s Stats) bool { return s.SyncRuns == 1 },
+		},
+		{
+			name:     "block timeout",
+			overflow: OverflowBlock,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), time.Millisecond*10)
+			},
+			wantError: context.DeadlineExceeded,
+			wantStats: func(s Stats) bool { return s.QueueDepth == 1 },
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			p, release := blocked(t, PoolWithQueueSize(1), PoolWithOverflow(tc.overflow))
+			defer release()
+			require.NoError(t, p.Submit(context.Background(), "", func() {}))
+			ctx, cancel := context.Background(), func() {}
+			if tc.ctx != nil {
+				ctx, cancel = tc.ctx()
+			}
+			defer cancel()
+			ran := false
+			err := p.Submit(ctx, "key", func() {
+				ran = true
+			})
+			assert.Equal(t, tc.wantError, err)
+			assert.Equal(t, tc.wantRun, ran)
+			assert.True(t, tc.wantStats(p.Stats()))
+			release()
+			require.NoError(t, p.Wait(context.Background()))
+			assert.Equal(t, tc.wantRun, ran)
+		})
+	}
+}
+
+func TestPool_Block(t *testing.T) {
+	p, release := blocked(t, PoolWithQueueSize(1))
+	require.NoError(t, p.Submit(context.Background(), "", func() {}))
+	done := make(chan error)
+	go func() {
+		done <- p.Submit(context.Background(), "", func() {})
+	}()
+	select {
+	case <-done:
+		t.Fatal("submit should block while queue is full")
+	case <-time.After(time.Millisecond * 20):
+	}
+	release()
+	assert.NoError(t, <-done)
+	require.NoError(t, p.Wait(context.Background()))
+	assert.Equal(t, uint64(3), p.Stats().Completed)
+}
+
+func TestPool_Close(t *testing.T) {
+	p, release := blocked(t, PoolWithQueueSize(4))
+	var cnt int64
+	for i := 0; i < 3; i++ {
+		require.NoError(t, p.Submit(context.Background(), "", func() {
+			atomic.AddInt64(&cnt, 1)
+		}))
+	}
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		release()
+	}()
+	//关闭时执行完队列中剩余的任务
+	require.NoError(t, p.Close(context.Background()))
+	assert.Equal(t, int64(3), atomic.LoadInt64(&cnt))
+	assert.Equal(t, cache.ErrClosed, p.Submit(context.Background(), "", func() {}))
+	assert.Equal(t, cache.ErrClosed, p.Close(context.Background()))
+}
@@ -0,0 +1,281 @@
+package write_back
+
+import (
+	"context"
+	"errors"
+	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/clock"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+var _ cache.Cache = &WriteBackCache{}
+
+type WriteBackCacheOption func(cache *WriteBackCache)
+
+// WriteBackCache 先写缓存，数据源的写入延后批量执行。同一个 key 在两次刷新之间的多次写入只保留最后一次，
+// 脏数据数量达到上限、定时刷新、脏 key 被淘汰或 Close 时调用 storeFunc 批量写入
+type WriteBackCache struct {
+	cache.Cache
+	storeFunc func(ctx context.Context, vals map[string][]byte) error
+
+	mutex *sync.Mutex
+	//dirty 尚未刷新的最新值，flushing 正在刷新的值，刷新失败时合并回 dirty
+	dirty    map[string][]byte
+	flushing map[string][]byte
+	//flushMutex 保证刷新串行执行，旧值不会覆盖新值
+	flushMutex *sync.Mutex
+
+	maxDirty   int
+	interval   time.Duration
+	clock      clock.Clock
+	errHandler func(ctx context.Context, keys []string, err error)
+
+	trigger chan struct{}
+	close   chan struct{}
+	//closed 拒绝新的写入，released 最终刷新成功且底层缓存已关闭
+	closed   bool
+	released bool
+	wg       *sync.WaitGroup
+}
+
+func NewWriteBackCache(c cache.Cache, storeFunc func(ctx context.Context, vals map[string][]byte) error,
+	opts ...WriteBackCacheOption) *WriteBackCache {
+	res := &WriteBackCache{
+		Cache:      c,
+		storeFunc:  storeFunc,
+		mutex:      &sync.Mutex{},
+		dirty:      map[string][]byte{},
+		flushing:   map[string][]byte{},
+		flushMutex: &sync.Mutex{},
+		maxDirty:   100,
+		interval:   time.Second,
+		clock:      clock.New(),
+		errHandler: func(ctx context.Context, keys []string, err error) {
+			log.Printf("cache: write back flush fail, keys: %v, err: %v", keys, err)
+		},
+		trigger: make(chan struct{}, 1),
+		close:   make(chan struct{}),
+		wg:      &sync.WaitGroup{},
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+	res.Cache.OnEvicted(res.evicted)
+	res.start()
+	return res
+}
+
+// WriteBackCacheWithMaxDirty 脏数据数量达到 n 时立即触发刷新，默认 100
+func WriteBackCacheWithMaxDirty(n int) WriteBackCacheOption {
+	return func(cache *WriteBackCache) {
+		cache.maxDirty = n
+	}
+}
+
+// WriteBackCacheWithFlushInterval 定时刷新的间隔，默认 1 秒
+func WriteBackCacheWithFlushInterval(interval time.Duration) WriteBackCacheOption {
+	return func(cache *WriteBackCache) {
+		cache.interval = interval
+	}
+}
+
+func WriteBackCacheWithClock(clock clock.Clock) WriteBackCacheOption {
+	return func(cache *WriteBackCache) {
+		cache.clock = clock
+	}
+}
+
+// WriteBackCacheWithErrorHandler 后台刷新失败时调用 fn，失败的 key 保留到下一次刷新，默认只打印日志
+func WriteBackCacheWithErrorHandler(fn func(ctx context.Context, keys []string, err error)) WriteBackCacheOption {
+	return func(cache *WriteBackCache) {
+		cache.errHandler = fn
+	}
+}
+
+func (c *WriteBackCache) start() {
+	ticker := c.clock.NewTicker(c.interval)
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C():
+			case <-c.trigger:
+			case <-c.close:
+				return
+			}
+			ctx := context.Background()
+			if keys, err := c.flush(ctx); err != nil {
+				c.errHandler(ctx, keys, err)
+			}
+		}
+	}()
+}
+
+// Set 写入缓存后记为脏数据，不等待数据源写入
+func (c *WriteBackCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closed {
+		return cache.ErrClosed
+	}
+	if err := c.Cache.Set(ctx, key, val, expiration); err != nil {
+		return err
+	}
+	c.dirty[key] = val
+	if len(c.dirty) >= c.maxDirty {
+		c.notify()
+	}
+	return nil
+}
+
+// Get 缓存中已被淘汰但尚未写入数据源的 key 返回未刷新的值
+func (c *WriteBackCache) Get(ctx context.Context, key string) ([]byte, error) {
+	val, err := c.Cache.Get(ctx, key)
+	if !errors.Is(err, cache.ErrKeyNotFound) {
+		return val, err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if v, ok := c.dirty[key]; ok {
+		return v, nil
+	}
+	if v, ok := c.flushing[key]; ok {
+		return v, nil
+	}
+	return val, err
+}
+
+// Delete 先把 key 未刷新的值写入数据源再从缓存中删除，写入失败时不删除
+func (c *WriteBackCache) Delete(ctx context.Context, key string) error {
+	if _, err := c.flush(ctx, key); err != nil {
+		return err
+	}
+	return c.Cache.Delete(ctx, key)
+}
+
+func (c *WriteBackCache) LoadAndDelete(ctx context.Context, key string) ([]byte, error) {
+	if _, err := c.flush(ctx, key); err != nil {
+		return nil, err
+	}
+	return c.Cache.LoadAndDelete(ctx, key)
+}
+
+func (c *WriteBackCache) OnEvicted(fn func(key string, val []byte)) {
+	c.Cache.OnEvicted(func(key string, val []byte) {
+		c.evicted(key, val)
+		fn(key, val)
+	})
+}
+
+// evicted 底层缓存淘汰 key 时触发后台刷新，刷新前 Get 仍能读到未写入的值。
+// 回调可能在 Set 持有锁时触发，这里不加锁判断 key 是否为脏数据
+func (c *WriteBackCache) evicted(key string, val []byte) {
+	c.notify()
+}
+
+// notify 非阻塞地唤醒后台刷新
+func (c *WriteBackCache) notify() {
+	select {
+	case c.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Flush 立即把所有脏数据写入数据源
+func (c *WriteBackCache) Flush(ctx context.Context) error {
+	_, err := c.flush(ctx)
+	return err
+}
+
+// flush keys 为空时刷新所有脏数据，失败时返回未写入的 key
+func (c *WriteBackCache) flush(ctx context.Context, keys ...string) ([]string, error) {
+	c.flushMutex.Lock()
+	defer c.flushMutex.Unlock()
+
+	c.mutex.Lock()
+	if len(keys) == 0 {
+		c.flushing, c.dirty = c.dirty, c.flushing
+	} else {
+		for _, key := range keys {
+			if val, ok := c.dirty[key]; ok {
+				c.flushing[key] = val
+				delete(c.dirty, key)
+			}
+		}
+	}
+	vals := c.flushing
+	c.mutex.Unlock()
+	if len(vals) == 0 {
+		return nil, nil
+	}
+
+	err := c.storeFunc(ctx, vals)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var failed []string
+	if err != nil {
+		//刷新期间写入的新值优先
+		for key, val := range vals {
+			if _, ok := c.dirty[key]; !ok {
+				c.dirty[key] = val
+			}
+			failed = append(failed, key)
+		}
+		sort.Strings(failed)
+	}
+	c.flushing = make(map[string][]byte, len(vals))
+	return failed, err
+}
+
+// DirtyKeys 尚未写入数据源的 key，包括正在刷新的 key，按字典序排列
+func (c *WriteBackCache) DirtyKeys() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	res := make([]string, 0, len(c.dirty)+len(c.flushing))
+	for key := range c.dirty {
+		res = append(res, key)
+	}
+	for key := range c.flushing {
+		if _, ok := c.dirty[key]; !ok {
+			res = append(res, key)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Close 停止后台刷新并把剩余的脏数据写入数据源，底层缓存实现了 cache.Closer 时一并关闭；
+// 最后一次刷新失败时脏数据保留，底层缓存尚未关闭，可以再次调用 Close
+func (c *WriteBackCache) Close(ctx context.Context) error {
+	c.mutex.Lock()
+	if c.released {
+		c.mutex.Unlock()
+		return cache.ErrClosed
+	}
+	if !c.closed {
+		c.closed = true
+		close(c.close)
+	}
+	c.mutex.Unlock()
+	c.wg.Wait()
+	if err := c.Flush(ctx); err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	if c.released {
+		c.mutex.Unlock()
+		return cache.ErrClosed
+	}
+	c.released = true
+	c.mutex.Unlock()
+	if closer, ok := c.Cache.(cache.Closer); ok {
+		return closer.Close(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package write_back
+
+import (
+	"context"
+	"errors"
+	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/clock/fake_clock"
+	"github.com/ac-zht/cache/local_cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+// store 记录每次批量写入，err 不为空时写入失败
+type store struct {
+	mutex   *sync.Mutex
+	batches []map[string]string
+	err     error
+}
+
+func newStore() *store {
+	return &store{mutex: &sync.Mutex{}}
+}
+
+func (s *store) storeFunc(ctx context.Context, vals map[string][]byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	batch := make(map[string]string, len(vals))
+	for key, val := range vals {
+		batch[key] = string(val)
+	}
+	s.batches = append(s.batches, batch)
+	return nil
+}
+
+func (s *store) get() []map[string]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]map[string]string(nil), s.batches...)
+}
+
+func (s *store) setErr(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.err = err
+}
+
+func TestWriteBackCache_Trigger(t *testing.T) {
+	testCase := []struct {
+		name    string
+		opts    []WriteBackCacheOption
+		trigger func(c *WriteBackCache, clk *fake_clock.FakeClock)
+	}{
+		{
+			name: "max dirty",
+			opts: []WriteBackCacheOption{WriteBackCacheWithMaxDirty(3)},
+			trigger: func(c *WriteBackCache, clk *fake_clock.FakeClock) {
+				require.NoError(t, c.Set(context.Background(), "k3", []byte("v3"), time.Minute))
+			},
+		},
+		{
+			name: "interval",
+			trigger: func(c *WriteBackCache, clk *fake_clock.FakeClock) {
+				clk.Advance(time.Second)
+			},
+		},
+		{
+			name: "close",
+			trigger: func(c *WriteBackCache, clk *fake_clock.FakeClock) {
+				require.NoError(t, c.Close(context.Background()))
+			},
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			clk := fake_clock.NewFakeClock(time.Now())
+			s := newStore()
+			c := NewWriteBackCache(local_cache.NewBytesCache(10), s.storeFunc,
+				append([]WriteBackCacheOption{WriteBackCacheWithClock(clk)}, tc.opts...)...)
+			ctx := context.Background()
+			//同一个 key 的多次写入合并
+			for _, val := range []string{"a", "b", "c"} {
+				require.NoError(t, c.Set(ctx, "k1", []byte(val), time.Minute))
+			}
+			require.NoError(t, c.Set(ctx, "k2", []byte("v2"), time.Minute))
+			assert.Equal(t, []string{"k1", "k2"}, c.DirtyKeys())
+			assert.Empty(t, s.get())
+
+			tc.trigger(c, clk)
+			assert.Eventually(t, func() bool {
+				return len(s.get()) == 1
+			}, time.Second, time.Millisecond*10)
+			assert.Equal(t, "c", s.get()[0]["k1"])
+			assert.Equal(t, "v2", s.get()[0]["k2"])
+			assert.Eventually(t, func() bool {
+				return len(c.DirtyKeys()) == 0
+			}, time.Second, time.Millisecond*10)
+		})
+	}
+}
+
+func TestWriteBackCache_Evicted(t *testing.T) {
+	clk := fake_clock.NewFakeClock(time.Now())
+	s := newStore()
+	lc := local_cache.NewBytesCache(10, local_cache.BuildInMapCacheWithClock(clk),
+		local_cache.BuildInMapCacheWithOutInterval(time.Second))
+	c := NewWriteBackCache(lc, s.storeFunc, WriteBackCacheWithClock(clk),
+		WriteBackCacheWithFlushInterval(time.Hour))
+	evicted := make(chan string, 1)
+	c.OnEvicted(func(key string, val []byte) {
+		evicted <- key
+	})
+	require.NoError(t, c.Set(context.Background(), "k1", []byte("v1"), time.Millisecond))
+
+	//过期清理淘汰脏 key 后触发刷新
+	clk.Advance(time.Second)
+	assert.Eventually(t, func() bool {
+		return len(s.get()) == 1
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, map[string]string{"k1": "v1"}, s.get()[0])
+	assert.Equal(t, "k1", <-evicted)
+	require.NoError(t, c.Close(context.Background()))
+}
+
+func TestWriteBackCache_FlushFail(t *testing.T) {
+	s := newStore()
+	s.setErr(errors.New("db down"))
+	var handled []string
+	clk := fake_clock.NewFakeClock(time.Now())
+	c := NewWriteBackCache(local_cache.NewBytesCache(10, local_cache.BuildInMapCacheWithClock(clk)), s.storeFunc,
+		WriteBackCacheWithErrorHandler(func(ctx context.Context, keys []string, err error) {
+			handled = keys
+		}))
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "k1", []byte("v1"), time.Minute))
+	//已过期但未写入数据源的 key 仍能读到
+	require.NoError(t, c.Set(ctx, "k2", []byte("v2"), time.Second))
+	clk.Advance(time.Second * 2)
+	val, err := c.Get(ctx, "k2")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v2"), val)
+
+	//写入数据源失败时不删除，脏数据保留
+	assert.Equal(t, s.err, c.Delete(ctx, "k1"))
+	assert.Equal(t, s.err, c.Flush(ctx))
+	assert.Equal(t, []string{"k1", "k2"}, c.DirtyKeys())
+	val, err = c.Get(ctx, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v1"), val)
+
+	s.setErr(nil)
+	require.NoError(t, c.Delete(ctx, "k1"))
+	assert.Equal(t, []map[string]string{{"k1": "v1"}}, s.get())
+	_, err = c.Get(ctx, "k1")
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+	assert.Equal(t, []string{"k2"}, c.DirtyKeys())
+
+	require.NoError(t, c.Close(ctx))
+	assert.Empty(t, c.DirtyKeys())
+	assert.Nil(t, handled)
+	assert.Equal(t, cache.ErrClosed, c.Set(ctx, "k3", []byte("v3"), time.Minute))
+	assert.Equal(t, cache.ErrClosed, c.Close(ctx))
+}
+
+func TestWriteBackCache_CloseFlushFail(t *testing.T) {
+	s := newStore()
+	s.setErr(errors.New("db down"))
+	lc := local_cache.NewBytesCache(10)
+	c := NewWriteBackCache(lc, s.storeFunc)
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "k1", []byte("v1"), time.Minute))
+
+	//最后一次刷新失败时拒绝写入，但脏数据和底层缓存都保留
+	assert.Equal(t, s.err, c.Close(ctx))
+	assert.Equal(t, cache.ErrClosed, c.Set(ctx, "k2", []byte("v2"), time.Minute))
+	assert.Equal(t, []string{"k1"}, c.DirtyKeys())
+	val, err := lc.Get(ctx, "k1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("v1"), val)
+
+	s.setErr(nil)
+	require.NoError(t, c.Close(ctx))
+	assert.Equal(t, []map[string]string{{"k1": "v1"}}, s.get())
+	_, err = lc.Get(ctx, "k1")
+	assert.Equal(t, cache.ErrClosed, err)
+	assert.Equal(t, cache.ErrClosed, c.Close(ctx))
+}
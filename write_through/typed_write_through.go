@@ -0,0 +1,29 @@
+package write_through
+
+import (
+	"context"
+	"github.com/ac-zht/cache"
+	"time"
+)
+
+// TypedWriteThroughCache 泛型 write through 缓存
+type TypedWriteThroughCache[K comparable, V any] struct {
+	cache.TypedCache[K, V]
+	storeFunc func(ctx context.Context, key K, val V) error
+}
+
+func NewTypedWriteThroughCache[K comparable, V any](c cache.TypedCache[K, V],
+	storeFunc func(ctx context.Context, key K, val V) error) *TypedWriteThroughCache[K, V] {
+	return &TypedWriteThroughCache[K, V]{
+		TypedCache: c,
+		storeFunc:  storeFunc,
+	}
+}
+
+// Set 先写库再写缓存同步操作
+func (c *TypedWriteThroughCache[K, V]) Set(ctx context.Context, key K, val V, expiration time.Duration) error {
+	if err := c.storeFunc(ctx, key, val); err != nil {
+		return err
+	}
+	return c.TypedCache.Set(ctx, key, val, expiration)
+}
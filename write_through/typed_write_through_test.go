@@ -0,0 +1,54 @@
+package write_through
+
+import (
+	"context"
+	"errors"
+	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/local_cache"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type user struct {
+	Id   int64
+	Name string
+}
+
+func TestTypedWriteThroughCache_Set(t *testing.T) {
+	testCase := []struct {
+		name      string
+		storeFunc func(ctx context.Context, key int64, val user) error
+		key       int64
+		val       user
+		wantError error
+	}{
+		{
+			name:      "success",
+			storeFunc: func(ctx context.Context, key int64, val user) error { return nil },
+			key:       1,
+			val:       user{Id: 1, Name: "Tom"},
+		},
+		{
+			name:      "fail",
+			storeFunc: func(ctx context.Context, key int64, val user) error { return errors.New("database store fail") },
+			key:       1,
+			val:       user{Id: 1, Name: "Tom"},
+			wantError: errors.New("database store fail"),
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			lc := local_cache.NewTypedBuildInMapCache[int64, user](10)
+			c := NewTypedWriteThroughCache[int64, user](lc, tc.storeFunc)
+			err := c.Set(context.Background(), tc.key, tc.val, time.Minute)
+			assert.Equal(t, tc.wantError, err)
+			val, getErr := lc.Get(context.Background(), tc.key)
+			if err != nil {
+				assert.ErrorIs(t, getErr, cache.ErrKeyNotFound)
+				return
+			}
+			assert.Equal(t, tc.val, val)
+		})
+	}
+}
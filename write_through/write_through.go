@@ -3,60 +3,231 @@ package write_through
 import (
 	"context"
 	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/internal/async"
+	"github.com/ac-zht/cache/metrics"
+	"github.com/ac-zht/cache/worker_pool"
 	"log"
 	"time"
 )
 
+type WriteThroughCacheOption func(cache *WriteThroughCache)
+
 type WriteThroughCache struct {
 	cache.Cache
 	storeFunc func(ctx context.Context, key string, val []byte) error
+
+	//errHandler 处理异步写入最终失败的错误，maxRetry 为失败后的重试次数，每次重试间隔翻倍
+	errHandler func(ctx context.Context, key string, err error)
+	maxRetry   int
+	backoff    time.Duration
+	//pool 不为空时异步写入提交到 pool 执行，否则每次启动一个 goroutine
+	pool *worker_pool.Group
+	//asyncTimeout 异步写入的超时时间，异步写入使用脱离调用方取消的 context
+	asyncTimeout time.Duration
+	//ttlFunc 不为空时代替调用方传入的过期时间，最后按 jitter 比例随机浮动
+	ttlFunc func(key string, val []byte) time.Duration
+	jitter  float64
+
+	//tracker 记录尚未完成的异步写入和关闭状态，Close 时等待其完成
+	tracker *async.Tracker
 }
 
-func NewWriteThroughCache(cache cache.Cache, storeFunc func(ctx context.Context, key string, val []byte) error) *WriteThroughCache {
+func NewWriteThroughCache(cache cache.Cache, storeFunc func(ctx context.Context, key string, val []byte) error,
+	opts ...WriteThroughCacheOption) *WriteThroughCache {
 	res := &WriteThroughCache{
 		Cache:     cache,
 		storeFunc: storeFunc,
+		errHandler: func(ctx context.Context, key string, err error) {
+			log.Printf("cache: async set fail, key: %s, err: %v", key, err)
+		},
+		asyncTimeout: time.Second * 10,
+		tracker:      async.NewTracker(),
+	}
+	for _, opt := range opts {
+		opt(res)
 	}
 	return res
 }
 
+// WriteThroughCacheWithErrorHandler 异步写入重试后仍失败时调用 fn，默认只打印日志
+func WriteThroughCacheWithErrorHandler(fn func(ctx context.Context, key string, err error)) WriteThroughCacheOption {
+	return func(cache *WriteThroughCache) {
+		cache.errHandler = fn
+	}
+}
+
+// WriteThroughCacheWithPool 异步写入交给 pool 执行，同一个 key 排队中的任务只执行最后一次提交的，
+// 多个缓存共享同一个 pool 时各自的任务互不合并；提交失败的错误交给 errHandler
+func WriteThroughCacheWithPool(pool *worker_pool.Pool) WriteThroughCacheOption {
+	return func(cache *WriteThroughCache) {
+		cache.pool = pool.NewGroup()
+	}
+}
+
+// WriteThroughCacheWithAsyncTimeout 异步写入的超时时间，默认 10 秒，为 0 时不超时。
+// 异步写入使用保留调用方 ctx 中的值但不随其取消的 context，超时从任务开始执行时计算
+func WriteThroughCacheWithAsyncTimeout(timeout time.Duration) WriteThroughCacheOption {
+	return func(cache *WriteThroughCache) {
+		cache.asyncTimeout = timeout
+	}
+}
+
+// WriteThroughCacheWithTTLJitter 写入缓存的过期时间在 [ttl*(1-percent), ttl*(1+percent)] 内随机浮动，
+// 避免同时写入的 key 同时过期，percent 取值 (0, 1)
+func WriteThroughCacheWithTTLJitter(percent float64) WriteThroughCacheOption {
+	return func(cache *WriteThroughCache) {
+		cache.jitter = percent
+	}
+}
+
+// WriteThroughCacheWithTTLFunc 按 key 和 value 计算写入缓存的过期时间，代替调用方传入的 expiration
+func WriteThroughCacheWithTTLFunc(fn func(key string, val []byte) time.Duration) WriteThroughCacheOption {
+	return func(cache *WriteThroughCache) {
+		cache.ttlFunc = fn
+	}
+}
+
+// WriteThroughCacheWithRetry 异步写入失败后最多重试 maxRetry 次，首次重试前等待 backoff，之后每次翻倍
+func WriteThroughCacheWithRetry(maxRetry int, backoff time.Duration) WriteThroughCacheOption {
+	return func(cache *WriteThroughCache) {
+		cache.maxRetry = maxRetry
+		cache.backoff = backoff
+	}
+}
+
 // Set 先写库再写缓存同步操作
 func (c *WriteThroughCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
+	if c.isClosed() {
+		return cache.ErrClosed
+	}
 	if err := c.storeFunc(ctx, key, val); err != nil {
 		return err
 	}
-	return c.Cache.Set(ctx, key, val, expiration)
+	return c.Cache.Set(ctx, key, val, c.ttl(key, val, expiration))
 }
 
 // SemiAsyncSet 先写库再写缓存半异步操作
 func (c *WriteThroughCache) SemiAsyncSet(ctx context.Context, key string, val []byte, expiration time.Duration) error {
+	if c.isClosed() {
+		return cache.ErrClosed
+	}
 	err := c.storeFunc(ctx, key, val)
-	go func() {
-		e := c.Cache.Set(ctx, key, val, expiration)
-		if e != nil {
-			log.Fatalln(e)
-		}
-	}()
+	ttl := c.ttl(key, val, expiration)
+	c.async(ctx, key, func(ctx context.Context) error {
+		return c.Cache.Set(ctx, key, val, ttl)
+	})
 	return err
 }
 
 // AsyncSet 先写库再写缓存全异步操作
 func (c *WriteThroughCache) AsyncSet(ctx context.Context, key string, val []byte, expiration time.Duration) error {
-	go func() {
-		if err := c.storeFunc(ctx, key, val); err != nil {
-			log.Fatalln(err)
-		}
-		if e := c.Cache.Set(ctx, key, val, expiration); e != nil {
-			log.Fatalln(e)
-		}
-	}()
+	if c.isClosed() {
+		return cache.ErrClosed
+	}
+	ttl := c.ttl(key, val, expiration)
+	c.async(ctx, key, func(ctx context.Context) error {
+		return c.storeFunc(ctx, key, val)
+	}, func(ctx context.Context) error {
+		return c.Cache.Set(ctx, key, val, ttl)
+	})
 	return nil
 }
 
 // SetV2 先写缓存再写库同步操作
 func (c *WriteThroughCache) SetV2(ctx context.Context, key string, val []byte, expiration time.Duration) error {
-	if err := c.Cache.Set(ctx, key, val, expiration); err != nil {
+	if c.isClosed() {
+		return cache.ErrClosed
+	}
+	if err := c.Cache.Set(ctx, key, val, c.ttl(key, val, expiration)); err != nil {
 		return err
 	}
 	return c.storeFunc(ctx, key, val)
 }
+
+// ttl 计算写入缓存的过期时间
+func (c *WriteThroughCache) ttl(key string, val []byte, expiration time.Duration) time.Duration {
+	if c.ttlFunc != nil {
+		expiration = c.ttlFunc(key, val)
+	}
+	return cache.JitterTTL(expiration, c.jitter)
+}
+
+// async 在后台依次执行 steps，每一步失败时按配置重试，最终仍失败时交给 errHandler 并跳过后续步骤
+func (c *WriteThroughCache) async(ctx context.Context, key string, steps ...func(ctx context.Context) error) {
+	run := func() {
+		ctx, cancel := c.asyncContext(ctx)
+		defer cancel()
+		for _, step := range steps {
+			if err := async.Retry(ctx, c.maxRetry, c.backoff, step, nil); err != nil {
+				c.errHandler(ctx, key, err)
+				return
+			}
+		}
+	}
+	//登记和关闭在同一把锁下判断，Close 开始等待后不会再有新的任务
+	if !c.tracker.Add() {
+		c.errHandler(ctx, key, cache.ErrClosed)
+		return
+	}
+	if c.pool != nil {
+		err := c.pool.Submit(ctx, key, run)
+		c.tracker.Done()
+		if err != nil {
+			c.errHandler(ctx, key, err)
+		}
+		return
+	}
+	go func() {
+		defer c.tracker.Done()
+		run()
+	}()
+}
+
+func (c *WriteThroughCache) asyncContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = cache.DetachContext(ctx)
+	if c.asyncTimeout > 0 {
+		return context.WithTimeout(ctx, c.asyncTimeout)
+	}
+	return ctx, func() {}
+}
+
+// Wait 等待已提交的异步写入全部完成，ctx 结束后不再等待；使用 pool 时只等待本缓存提交的任务
+func (c *WriteThroughCache) Wait(ctx context.Context) error {
+	if err := c.tracker.Wait(ctx); err != nil {
+		return err
+	}
+	if c.pool != nil {
+		return c.pool.Wait(ctx)
+	}
+	return nil
+}
+
+// Stats 本层不改变读取和淘汰，直接返回底层缓存的统计，底层缓存不提供统计时返回空值
+func (c *WriteThroughCache) Stats() metrics.Stats {
+	if s, ok := c.Cache.(interface{ Stats() metrics.Stats }); ok {
+		return s.Stats()
+	}
+	return metrics.Stats{}
+}
+
+// Close 拒绝新的操作，等待未完成的异步写入后关闭实现了 cache.Closer 的底层缓存；
+// ctx 结束导致等待失败时底层缓存尚未关闭，可以再次调用 Close
+func (c *WriteThroughCache) Close(ctx context.Context) error {
+	if !c.tracker.Close() {
+		return cache.ErrClosed
+	}
+	if err := c.Wait(ctx); err != nil {
+		return err
+	}
+	if !c.tracker.Release() {
+		return cache.ErrClosed
+	}
+	if closer, ok := c.Cache.(cache.Closer); ok {
+		return closer.Close(ctx)
+	}
+	return nil
+}
+
+func (c *WriteThroughCache) isClosed() bool {
+	return c.tracker.Closed()
+}
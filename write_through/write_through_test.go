@@ -5,7 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"github.com/ac-zht/cache"
+	"github.com/ac-zht/cache/local_cache"
+	"github.com/ac-zht/cache/worker_pool"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 )
@@ -59,6 +64,279 @@ func TestWriteThroughCache_Get(t *testing.T) {
 	}
 }
 
+func TestWriteThroughCache_Close(t *testing.T) {
+	lc := local_cache.NewBytesCache(10)
+	stored := &sync.Map{}
+	c := NewWriteThroughCache(lc, func(ctx context.Context, key string, val []byte) error {
+		time.Sleep(time.Millisecond * 50)
+		stored.Store(key, val)
+		return nil
+	})
+	assert.NoError(t, c.AsyncSet(context.Background(), "key", []byte("value"), time.Minute))
+
+	//Close 等待异步写入完成
+	assert.NoError(t, c.Close(context.Background()))
+	val, ok := stored.Load("key")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value"), val)
+	assert.Equal(t, cache.ErrClosed, c.Set(context.Background(), "key", []byte("value"), time.Minute))
+	_, err := lc.Get(context.Background(), "key")
+	assert.Equal(t, cache.ErrClosed, err)
+}
+
+func TestWriteThroughCache_CloseTimeout(t *testing.T) {
+	lc := local_cache.NewBytesCache(10)
+	stored := &sync.Map{}
+	c := NewWriteThroughCache(lc, func(ctx context.Context, key string, val []byte) error {
+		time.Sleep(time.Millisecond * 100)
+		stored.Store(key, val)
+		return nil
+	})
+	assert.NoError(t, c.AsyncSet(context.Background(), "key", []byte("value"), time.Minute))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, c.Close(ctx))
+	assert.Equal(t, cache.ErrClosed, c.AsyncSet(context.Background(), "k2", []byte("v2"), time.Minute))
+
+	//等待超时后可以再次 Close，完成后底层缓存被关闭
+	assert.NoError(t, c.Close(context.Background()))
+	_, ok := stored.Load("key")
+	assert.True(t, ok)
+	_, err := lc.Get(context.Background(), "key")
+	assert.Equal(t, cache.ErrClosed, err)
+	assert.Equal(t, cache.ErrClosed, c.Close(context.Background()))
+}
+
+func TestWriteThroughCache_AsyncError(t *testing.T) {
+	testCase := []struct {
+		name          string
+		set           func(c *WriteThroughCache, ctx context.Context, key string, val []byte, expiration time.Duration) error
+		storeFailures int
+		setErrFlag    bool
+		wantStores    int
+		wantHandled   error
+		wantData      map[string][]byte
+	}{
+		{
+			name:          "store success after retry",
+			set:           (*WriteThroughCache).AsyncSet,
+			storeFailures: 2,
+			wantStores:    3,
+			wantData:      map[string][]byte{"key": []byte("value")},
+		},
+		{
+			name:          "store fail skip cache",
+			set:           (*WriteThroughCache).AsyncSet,
+			storeFailures: 10,
+			wantStores:    3,
+			wantHandled:   errors.New("store error"),
+			wantData:      map[string][]byte{},
+		},
+		{
+			name:        "semi async set cache fail",
+			set:         (*WriteThroughCache).SemiAsyncSet,
+			setErrFlag:  true,
+			wantStores:  1,
+			wantHandled: errors.New("set error: key : key"),
+			wantData:    map[string][]byte{},
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			mc := &MockCache{data: map[string][]byte{}, setErrFlag: tc.setErrFlag}
+			stores := 0
+			var handled error
+			c := NewWriteThroughCache(mc, func(ctx context.Context, key string, val []byte) error {
+				stores++
+				if stores <= tc.storeFailures {
+					return errors.New("store error")
+				}
+				return nil
+			},
+				WriteThroughCacheWithRetry(2, time.Millisecond),
+				WriteThroughCacheWithErrorHandler(func(ctx context.Context, key string, err error) {
+					handled = err
+				}),
+			)
+			_ = tc.set(c, context.Background(), "key", []byte("value"), time.Minute)
+			assert.NoError(t, c.Wait(context.Background()))
+			assert.Equal(t, tc.wantStores, stores)
+			assert.Equal(t, tc.wantHandled, handled)
+			assert.Equal(t, tc.wantData, mc.data)
+		})
+	}
+}
+
+func TestWriteThroughCache_Pool(t *testing.T) {
+	pool := worker_pool.NewPool(worker_pool.PoolWithWorkers(1))
+	gate, started := make(chan struct{}), make(chan struct{})
+	require.NoError(t, pool.Submit(context.Background(), "", func() {
+		close(started)
+		<-gate
+	}))
+	<-started
+	var stored []string
+	mc := &MockCache{data: map[string][]byte{}}
+	c := NewWriteThroughCache(mc, func(ctx context.Context, key string, val []byte) error {
+		stored = append(stored, string(val))
+		return nil
+	}, WriteThroughCacheWithPool(pool))
+
+	//worker 被占用期间同一个 key 的写入合并，只写入最后一次的值
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, c.AsyncSet(context.Background(), "key", []byte(strconv.Itoa(i)), time.Minute))
+	}
+	assert.Equal(t, 1, pool.Stats().QueueDepth)
+	close(gate)
+	require.NoError(t, c.Close(context.Background()))
+	assert.Equal(t, []string{"4"}, stored)
+	assert.Equal(t, map[string][]byte{"key": []byte("4")}, mc.data)
+	assert.Equal(t, uint64(4), pool.Stats().Coalesced)
+}
+
+func TestWriteThroughCache_SharedPool(t *testing.T) {
+	pool := worker_pool.NewPool(worker_pool.PoolWithWorkers(1))
+	gate, started := make(chan struct{}), make(chan struct{})
+	require.NoError(t, pool.Submit(context.Background(), "", func() {
+		close(started)
+		<-gate
+	}))
+	<-started
+	stored := &sync.Map{}
+	newCache := func(name string) *WriteThroughCache {
+		return NewWriteThroughCache(&MockCache{data: map[string][]byte{}}, func(ctx context.Context, key string, val []byte) error {
+			stored.Store(name+"/"+key, string(val))
+			return nil
+		}, WriteThroughCacheWithPool(pool))
+	}
+	c1, c2 := newCache("c1"), newCache("c2")
+
+	//不同缓存中相同的 key 不合并
+	assert.NoError(t, c1.AsyncSet(context.Background(), "1", []byte("a"), time.Minute))
+	assert.NoError(t, c2.AsyncSet(context.Background(), "1", []byte("b"), time.Minute))
+	assert.Equal(t, 2, pool.Stats().QueueDepth)
+
+	//c1 只等待自己的任务
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, c1.Wait(ctx))
+	close(gate)
+	require.NoError(t, c1.Wait(context.Background()))
+	require.NoError(t, c2.Close(context.Background()))
+	val, _ := stored.Load("c1/1")
+	assert.Equal(t, "a", val)
+	val, _ = stored.Load("c2/1")
+	assert.Equal(t, "b", val)
+}
+
+type traceKey struct{}
+
+func TestWriteThroughCache_DetachedContext(t *testing.T) {
+	testCase := []struct {
+		name        string
+		timeout     time.Duration
+		storeFunc   func(ctx context.Context, key string, val []byte) error
+		wantHandled error
+		wantData    map[string][]byte
+	}{
+		{
+			name:    "complete after request cancelled",
+			timeout: time.Second,
+			storeFunc: func(ctx context.Context, key string, val []byte) error {
+				time.Sleep(time.Millisecond * 20)
+				if ctx.Value(traceKey{}) != "trace-1" {
+					return errors.New("trace id lost")
+				}
+				return ctx.Err()
+			},
+			wantData: map[string][]byte{"key": []byte("value")},
+		},
+		{
+			name:    "async timeout",
+			timeout: time.Millisecond * 10,
+			storeFunc: func(ctx context.Context, key string, val []byte) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			wantHandled: context.DeadlineExceeded,
+			wantData:    map[string][]byte{},
+		},
+	}
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			mc := &MockCache{data: map[string][]byte{}}
+			var handled error
+			c := NewWriteThroughCache(mc, tc.storeFunc,
+				WriteThroughCacheWithAsyncTimeout(tc.timeout),
+				WriteThroughCacheWithErrorHandler(func(ctx context.Context, key string, err error) {
+					handled = err
+				}),
+			)
+			ctx, cancel := context.WithCancel(context.WithValue(context.Background(), traceKey{}, "trace-1"))
+			assert.NoError(t, c.AsyncSet(ctx, "key", []byte("value"), time.Minute))
+			//请求结束，ctx 被取消
+			cancel()
+			require.NoError(t, c.Wait(context.Background()))
+			assert.Equal(t, tc.wantHandled, handled)
+			assert.Equal(t, tc.wantData, mc.data)
+		})
+	}
+}
+
+func TestWriteThroughCache_TTL(t *testing.T) {
+	testCases := []struct {
+		name    string
+		opts    []WriteThroughCacheOption
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{
+			name:    "fixed",
+			wantMin: time.Minute,
+			wantMax: time.Minute,
+		},
+		{
+			name:    "jitter",
+			opts:    []WriteThroughCacheOption{WriteThroughCacheWithTTLJitter(0.2)},
+			wantMin: time.Second * 48,
+			wantMax: time.Second * 72,
+		},
+		{
+			name: "ttl func",
+			opts: []WriteThroughCacheOption{WriteThroughCacheWithTTLFunc(func(key string, val []byte) time.Duration {
+				return time.Second * time.Duration(len(val))
+			})},
+			wantMin: time.Second * 2,
+			wantMax: time.Second * 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mc := &ttlCache{MockCache: MockCache{data: map[string][]byte{}}, ttls: map[string]time.Duration{}}
+			c := NewWriteThroughCache(mc, func(ctx context.Context, key string, val []byte) error {
+				return nil
+			}, tc.opts...)
+			for i := 0; i < 20; i++ {
+				key := strconv.Itoa(i)
+				require.NoError(t, c.Set(context.Background(), key, []byte("v1"), time.Minute))
+				assert.GreaterOrEqual(t, mc.ttls[key], tc.wantMin)
+				assert.LessOrEqual(t, mc.ttls[key], tc.wantMax)
+			}
+		})
+	}
+}
+
+// ttlCache 记录每个 key 写入时的过期时间
+type ttlCache struct {
+	MockCache
+	ttls map[string]time.Duration
+}
+
+func (c *ttlCache) Set(ctx context.Context, key string, val []byte, expiration time.Duration) error {
+	c.ttls[key] = expiration
+	return c.MockCache.Set(ctx, key, val, expiration)
+}
+
 type MockCache struct {
 	cache.Cache
 	data       map[string][]byte
@@ -85,3 +363,20 @@ func (c *MockCache) Delete(ctx context.Context, key string) error {
 	delete(c.data, key)
 	return nil
 }
+
+func TestWriteThroughCache_Stats(t *testing.T) {
+	c := NewWriteThroughCache(local_cache.NewBytesCache(10), func(ctx context.Context, key string, val []byte) error {
+		return nil
+	})
+	ctx := context.Background()
+	assert.NoError(t, c.Set(ctx, "k1", []byte("v1"), time.Minute))
+	_, err := c.Get(ctx, "k1")
+	assert.NoError(t, err)
+	_, err = c.Get(ctx, "k2")
+	assert.ErrorIs(t, err, cache.ErrKeyNotFound)
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(1), stats.Sets)
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+}